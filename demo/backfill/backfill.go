@@ -0,0 +1,236 @@
+package backfill
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"demo/model"
+	"demo/params"
+	"demo/runner"
+)
+
+// RenderedStep 是某个周期下替换了运行参数之后的单个步骤脚本。
+type RenderedStep struct {
+	Step model.Step
+	// Order 为步骤在周期内的执行顺序（拓扑序，从 1 开始）。
+	Order  int
+	Script string
+}
+
+// PeriodScripts 保存一个周期内按顺序排列的全部步骤脚本。
+type PeriodScripts struct {
+	Period string
+	Values map[string]string
+	Steps  []RenderedStep
+}
+
+// Plan 是一次回填的完整渲染结果，周期按时间先后排列。
+type Plan struct {
+	Process     string
+	Granularity model.Granularity
+	Load        model.LoadType
+	Periods     []PeriodScripts
+}
+
+// Options 控制回填的渲染方式。
+type Options struct {
+	// Load 为要回填的加载类型，为空时默认只回填增量步骤。
+	Load model.LoadType
+	// ExpandDateWindows 为 true 时在 Go 中预先计算日期窗口表达式。
+	ExpandDateWindows bool
+}
+
+// Periods 返回 [start, end] 区间内的所有周期值（含首尾），格式由粒度决定，例如 202401…202406。
+func Periods(start, end string, g model.Granularity) ([]string, error) {
+	from, err := params.ParsePeriod(start, g)
+	if err != nil {
+		return nil, err
+	}
+	to, err := params.ParsePeriod(end, g)
+	if err != nil {
+		return nil, err
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("开始周期 %s 晚于结束周期 %s", start, end)
+	}
+
+	var periods []string
+	for t := from; !t.After(to); t = params.Shift(t, g, 1) {
+		period, _ := params.FormatPeriod(t, g)
+		periods = append(periods, period)
+	}
+	return periods, nil
+}
+
+// anchorParam 返回与回填粒度相同的第一个参数，周期值即为该参数的取值。
+func anchorParam(decls []model.RunParam, g model.Granularity) (model.RunParam, error) {
+	for _, d := range decls {
+		if d.Granularity == g {
+			return d, nil
+		}
+	}
+	return model.RunParam{}, fmt.Errorf("流程没有声明 %s 粒度的参数", g)
+}
+
+// Build 为区间内的每个周期渲染流程中指定加载类型的步骤。
+func Build(process *model.DemoProcess, start, end string, g model.Granularity, opts Options) (*Plan, error) {
+	periods, err := Periods(start, end, g)
+	if err != nil {
+		return nil, err
+	}
+
+	load := opts.Load
+	if load == "" {
+		load = model.IncrementalLoad
+	}
+	decls := params.Declared(process)
+	anchor, err := anchorParam(decls, g)
+	if err != nil {
+		return nil, err
+	}
+
+	// 周期内的步骤严格串行，按依赖关系的拓扑序执行。
+	steps, err := process.TopologicalOrder(load)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("流程 %s 没有加载类型为 %s 的步骤", process.Name, load)
+	}
+
+	plan := &Plan{Process: process.Name, Granularity: g, Load: load}
+	for _, period := range periods {
+		t, _ := params.ParsePeriod(period, g)
+		// 周期是锚点参数的取值，因此业务日期需要抵消掉该参数的偏移量。
+		values, err := params.Resolve(decls, params.Shift(t, g, -anchor.Offset))
+		if err != nil {
+			return nil, err
+		}
+		// 每个周期各自使用独立的运行 ID，中间目录因此互不冲突。
+		values = runner.WithRunID(values, runner.RunID(process.Name, load, values))
+
+		ps := PeriodScripts{Period: period, Values: values}
+		for i, s := range steps {
+			content := s.Content
+			if opts.ExpandDateWindows {
+				content = params.ExpandDateWindows(content, values)
+			}
+			script, err := params.Render(content, values)
+			if err != nil {
+				return nil, fmt.Errorf("周期 %s 步骤 %d: %w", period, s.ID, err)
+			}
+			ps.Steps = append(ps.Steps, RenderedStep{Step: s, Order: i + 1, Script: script})
+		}
+		plan.Periods = append(plan.Periods, ps)
+	}
+	return plan, nil
+}
+
+// hasDMSteps 返回回填中是否有达梦存储过程步骤。
+func (p *Plan) hasDMSteps() bool {
+	for _, ps := range p.Periods {
+		for _, r := range ps.Steps {
+			if r.Step.ResolvedCommandType() == model.DMProcCommand {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scriptExtension 决定脚本文件的扩展名，驱动脚本根据扩展名选择执行命令。
+func scriptExtension(t model.CommandType) string {
+	switch t {
+	case model.HiveSQLCommand:
+		return ".hql"
+	case model.DMProcCommand:
+		return ".dm.sql"
+	default:
+		return ".sh"
+	}
+}
+
+// FileName 返回步骤脚本在周期目录下的文件名，例如 003_step7.sh：执行顺序补零在前，
+// 保证按文件名排序即为执行顺序，其后是步骤 ID。
+func (r RenderedStep) FileName() string {
+	return fmt.Sprintf("%03d_step%d%s", r.Order, r.Step.ID, scriptExtension(r.Step.ResolvedCommandType()))
+}
+
+// WriteFiles 将每个周期的脚本写入 dir/<周期>/ 目录，返回写入的文件路径。
+func (p *Plan) WriteFiles(dir string) ([]string, error) {
+	var written []string
+	for _, ps := range p.Periods {
+		periodDir := filepath.Join(dir, ps.Period)
+		if err := os.MkdirAll(periodDir, 0o755); err != nil {
+			return written, err
+		}
+		for _, r := range ps.Steps {
+			path := filepath.Join(periodDir, r.FileName())
+			if err := os.WriteFile(path, []byte(r.Script+"\n"), 0o644); err != nil {
+				return written, err
+			}
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
+
+// DriverScript 生成按周期顺序调度、最多 parallel 个周期并行执行的 bash 驱动脚本。
+// 同一周期内的步骤严格串行，任何一步失败都会终止该周期并使驱动脚本以非零状态退出。
+func (p *Plan) DriverScript(parallel int) string {
+	if parallel < 1 {
+		parallel = 1
+	}
+	var periods []string
+	for _, ps := range p.Periods {
+		periods = append(periods, ps.Period)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#!/bin/bash\n")
+	sb.WriteString(fmt.Sprintf("# 回填 %s（%s）: %s ~ %s，共 %d 个周期\n", p.Process, p.Load, periods[0], periods[len(periods)-1], len(periods)))
+	sb.WriteString("set -u\n\n")
+	sb.WriteString(`BASE_DIR="$(cd "$(dirname "$0")" && pwd)"` + "\n")
+	sb.WriteString(`HIVE_CMD="${HIVE_CMD:-beeline -f}"` + "\n")
+	// 只有包含达梦存储过程步骤时才要求设置 DM_CMD，否则没有达梦环境也能回填。
+	if p.hasDMSteps() {
+		sb.WriteString(`DM_CMD="${DM_CMD:?请设置 DM_CMD 为执行达梦 SQL 文件的命令}"` + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("MAX_PARALLEL=\"${MAX_PARALLEL:-%d}\"\n\n", parallel))
+
+	sb.WriteString(`run_period() {
+  local period="$1"
+  for f in "$BASE_DIR/$period"/*; do
+    echo "[$period] $(basename "$f")"
+    case "$f" in
+      *.hql) $HIVE_CMD "$f" ;;
+      *.dm.sql) $DM_CMD "$f" ;;
+      *) bash "$f" ;;
+    esac || { echo "[$period] 失败: $(basename "$f")" >&2; touch "$BASE_DIR/$period/.failed"; return 1; }
+  done
+}
+
+`)
+	sb.WriteString(fmt.Sprintf("PERIODS=(%s)\n", strings.Join(periods, " ")))
+	sb.WriteString(`for period in "${PERIODS[@]}"; do
+  rm -f "$BASE_DIR/$period/.failed"
+  run_period "$period" &
+  while [ "$(jobs -rp | wc -l)" -ge "$MAX_PARALLEL" ]; do
+    wait -n
+  done
+done
+wait
+
+failed=0
+for period in "${PERIODS[@]}"; do
+  if [ -e "$BASE_DIR/$period/.failed" ]; then
+    echo "周期 $period 执行失败" >&2
+    failed=1
+  fi
+done
+exit $failed
+`)
+	return sb.String()
+}
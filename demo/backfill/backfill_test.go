@@ -0,0 +1,35 @@
+package backfill
+
+import (
+	"reflect"
+	"testing"
+
+	"demo/model"
+)
+
+func TestBuildTopologicalOrder(t *testing.T) {
+	// 步骤 ID 的顺序与依赖关系相反：步骤 1 依赖 3，步骤 3 依赖 2。
+	p := &model.DemoProcess{Name: "demo", Steps: []model.Step{
+		{ID: 1, Load: model.IncrementalLoad, CommandType: model.DMProcCommand, DependsOn: []int{3}, Content: "p_create_mid_app('T_APP_X', null);"},
+		{ID: 2, Load: model.IncrementalLoad, CommandType: model.HiveSQLCommand, Content: "select '${mt1}'"},
+		{ID: 3, Load: model.IncrementalLoad, CommandType: model.ShellCommand, DependsOn: []int{2}, Content: "echo ${mt1}"},
+		{ID: 4, Load: model.InitializationLoad, CommandType: model.ShellCommand, Content: "echo init"},
+	}}
+	plan, err := Build(p, "202401", "202402", model.MonthGranularity, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Periods) != 2 {
+		t.Fatalf("got %d periods, want 2", len(plan.Periods))
+	}
+	want := []string{"001_step2.hql", "002_step3.sh", "003_step1.dm.sql"}
+	for _, ps := range plan.Periods {
+		var names []string
+		for _, r := range ps.Steps {
+			names = append(names, r.FileName())
+		}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("period %s: files %v, want %v", ps.Period, names, want)
+		}
+	}
+}
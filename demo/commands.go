@@ -0,0 +1,911 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"demo/backfill"
+	"demo/diagram"
+	"demo/generator"
+	"demo/lineage"
+	"demo/lint"
+	"demo/model"
+	"demo/params"
+	"demo/parser"
+	"demo/runner"
+	"demo/scheduler"
+)
+
+// runCommand dispatches a CLI subcommand.
+func runCommand(name string, args []string) error {
+	switch name {
+	case "render":
+		return renderCommand(args)
+	case "backfill":
+		return backfillCommand(args)
+	case "run":
+		return runProcessCommand(args)
+	case "resume":
+		return resumeCommand(args)
+	case "rerun":
+		return rerunCommand(args)
+	case "export":
+		return exportCommand(args)
+	case "validate":
+		return validateCommand(args)
+	case "write":
+		return writeCommand(args)
+	case "import-sql":
+		return importSQLCommand(args)
+	case "lineage":
+		return lineageCommand(args)
+	case "impact":
+		return impactCommand(args)
+	case "graph":
+		return graphCommand(args)
+	case "lint":
+		return lintCommand(args)
+	default:
+		return fmt.Errorf("unknown command %q (available: render, backfill, run, resume, rerun, export, validate, write, import-sql, lineage, impact, graph, lint)", name)
+	}
+}
+
+// parseBizDate parses the -date flag; an empty value means today.
+func parseBizDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// filterSteps keeps the steps of the given load type; an empty load keeps every step.
+func filterSteps(steps []model.Step, load string) []model.Step {
+	if load == "" {
+		return steps
+	}
+	var filtered []model.Step
+	for _, s := range steps {
+		if string(s.Load) == load {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// renderCommand prints every step of a process with its run parameters substituted.
+func renderCommand(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	file := fs.String("file", "", "demo.txt style process file (default: built-in DemoProcess)")
+	date := fs.String("date", "", "business date, yyyy-MM-dd (default: today)")
+	load := fs.String("load", "", "only render steps of this load type (初始化 or 增量)")
+	expand := fs.Bool("expand", false, "pre-compute Hive date window expressions in Go")
+	udfsFile := addUDFsFlag(fs)
+	fs.Parse(args)
+
+	udfs, err := readUDFs(*udfsFile)
+	if err != nil {
+		return err
+	}
+	process, err := loadProcess(*file, udfs)
+	if err != nil {
+		return err
+	}
+	bizDate, err := parseBizDate(*date)
+	if err != nil {
+		return err
+	}
+
+	decls := params.Declared(process)
+	values, err := params.Resolve(decls, bizDate)
+	if err != nil {
+		return err
+	}
+
+	steps := filterSteps(process.Steps, *load)
+	var scripts []string
+	for _, s := range steps {
+		scripts = append(scripts, s.Content)
+	}
+	undeclared, unused := params.Check(scripts, decls)
+	if len(undeclared) > 0 {
+		return fmt.Errorf("undeclared parameters: %v", undeclared)
+	}
+	if len(unused) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: declared but unused parameters: %v\n", unused)
+	}
+	values = runner.WithRunID(values, runner.RunID(process.Name, model.LoadType(*load), values))
+
+	for _, s := range steps {
+		content := s.Content
+		if *expand {
+			content = params.ExpandDateWindows(content, values)
+		}
+		rendered, err := params.Render(content, values)
+		if err != nil {
+			return fmt.Errorf("step %d: %w", s.ID, err)
+		}
+		fmt.Printf("-- %d. %s（%s）\n%s\n\n", s.ID, s.Name, s.Load, rendered)
+	}
+	return nil
+}
+
+// backfillCommand renders the steps of a process for every period in a range, either to
+// stdout or as per-period script files plus a driver shell script.
+func backfillCommand(args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	file := fs.String("file", "", "demo.txt style process file (default: built-in DemoProcess)")
+	start := fs.String("start", "", "first period, e.g. 202401 (required)")
+	end := fs.String("end", "", "last period, e.g. 202406 (default: -start)")
+	granularity := fs.String("granularity", string(model.MonthGranularity), "period granularity: month or day")
+	load := fs.String("load", string(model.IncrementalLoad), "load type to backfill (初始化 or 增量)")
+	expand := fs.Bool("expand", false, "pre-compute Hive date window expressions in Go")
+	out := fs.String("out", "", "write per-period scripts and run_backfill.sh into this directory")
+	parallel := fs.Int("parallel", 1, "maximum number of periods the driver script runs concurrently")
+	udfsFile := addUDFsFlag(fs)
+	fs.Parse(args)
+
+	if *start == "" {
+		return fmt.Errorf("-start is required")
+	}
+	if *end == "" {
+		*end = *start
+	}
+	udfs, err := readUDFs(*udfsFile)
+	if err != nil {
+		return err
+	}
+	process, err := loadProcess(*file, udfs)
+	if err != nil {
+		return err
+	}
+
+	plan, err := backfill.Build(process, *start, *end, model.Granularity(*granularity), backfill.Options{
+		Load:              model.LoadType(*load),
+		ExpandDateWindows: *expand,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		for _, ps := range plan.Periods {
+			fmt.Printf("==================== %s ====================\n", ps.Period)
+			for _, r := range ps.Steps {
+				fmt.Printf("-- %d. %s（%s）\n%s\n\n", r.Step.ID, r.Step.Name, r.Step.Load, r.Script)
+			}
+		}
+		return nil
+	}
+
+	written, err := plan.WriteFiles(*out)
+	if err != nil {
+		return err
+	}
+	driver := filepath.Join(*out, "run_backfill.sh")
+	if err := os.WriteFile(driver, []byte(plan.DriverScript(*parallel)), 0o755); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %d scripts for %d periods, driver: %s\n", len(written), len(plan.Periods), driver)
+	return nil
+}
+
+// runnerFlags holds the flags shared by the run, resume and rerun commands.
+type runnerFlags struct {
+	file     *string
+	stateDir *string
+	dryRun   *bool
+	shellCmd *string
+	hiveCmd  *string
+	dmCmd    *string
+	parallel *int
+	udfs     *string
+}
+
+func addRunnerFlags(fs *flag.FlagSet) *runnerFlags {
+	return &runnerFlags{
+		file:     fs.String("file", "", "demo.txt style process file (default: built-in DemoProcess)"),
+		stateDir: fs.String("state-dir", ".workflow-state", "directory holding per-run step state files"),
+		dryRun:   fs.Bool("dry-run", false, "only print the resolved commands and scripts"),
+		shellCmd: fs.String("shell-cmd", runner.DefaultShellCommand, "command template for shell steps"),
+		hiveCmd:  fs.String("hive-cmd", runner.DefaultHiveCommand, "command template for hivesql steps ({file} or {script})"),
+		dmCmd:    fs.String("dm-cmd", "", "command template for dm_proc steps, e.g. \"disql USER/PWD@HOST:5236 `{file}\""),
+		parallel: fs.Int("parallel", 1, "maximum number of independent steps executed concurrently"),
+		udfs:     addUDFsFlag(fs),
+	}
+}
+
+// loadProcess loads the -file process with the -udfs functions registered.
+func (f *runnerFlags) loadProcess() (*model.DemoProcess, error) {
+	udfs, err := readUDFs(*f.udfs)
+	if err != nil {
+		return nil, err
+	}
+	return loadProcess(*f.file, udfs)
+}
+
+// addUDFsFlag registers the -udfs flag of the commands that render, run or export steps.
+func addUDFsFlag(fs *flag.FlagSet) *string {
+	return fs.String("udfs", "", "custom function registry; Hive steps calling its functions get ADD JAR/CREATE TEMPORARY FUNCTION statements")
+}
+
+// newRunner creates a runner whose failure handlers stop when abort is closed.
+func (f *runnerFlags) newRunner(abort <-chan struct{}) *runner.Runner {
+	r := runner.New(runner.Config{ShellCommand: *f.shellCmd, HiveCommand: *f.hiveCmd, DMCommand: *f.dmCmd})
+	r.DryRun = *f.dryRun
+	r.Out = os.Stdout
+	r.State = &runner.StateStore{Dir: *f.stateDir}
+	r.MaxParallel = *f.parallel
+	r.Abort = abort
+	return r
+}
+
+// interruptContext returns a context cancelled on the first Ctrl-C or SIGTERM, so running
+// steps are killed and not retried, and a channel closed on the second one, which stops the
+// failure handlers cleaning up after the interrupted run.
+func interruptContext() (context.Context, <-chan struct{}, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	abort := make(chan struct{})
+	done := make(chan struct{})
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for n := 0; n < 2; n++ {
+			select {
+			case <-signals:
+			case <-done:
+				return
+			}
+			if n == 0 {
+				cancel()
+			} else {
+				close(abort)
+			}
+		}
+	}()
+	return ctx, abort, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// reportRun prints the outcome of a run, including stderr of the failed step.
+func reportRun(runID string, results []runner.Result, err error) error {
+	if err != nil {
+		if n := len(results); n > 0 && results[n-1].Stderr != "" {
+			fmt.Fprintln(os.Stderr, results[n-1].Stderr)
+		}
+		return fmt.Errorf("run %s: %w", runID, err)
+	}
+	fmt.Printf("run %s: %d steps finished\n", runID, len(results))
+	return nil
+}
+
+// runProcessCommand executes the steps of a process locally through the runner package.
+func runProcessCommand(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	rf := addRunnerFlags(fs)
+	date := fs.String("date", "", "business date, yyyy-MM-dd (default: today)")
+	load := fs.String("load", string(model.IncrementalLoad), "load type to run (初始化 or 增量)")
+	runID := fs.String("run-id", "", "run identifier (default: derived from process, load type and parameters)")
+	fs.Parse(args)
+
+	process, err := rf.loadProcess()
+	if err != nil {
+		return err
+	}
+	bizDate, err := parseBizDate(*date)
+	if err != nil {
+		return err
+	}
+	values, err := params.Resolve(params.Declared(process), bizDate)
+	if err != nil {
+		return err
+	}
+	if *runID == "" {
+		*runID = runner.RunID(process.Name, model.LoadType(*load), values)
+	}
+
+	ctx, abort, stop := interruptContext()
+	defer stop()
+	results, err := rf.newRunner(abort).Run(ctx, *runID, process, model.LoadType(*load), values)
+	return reportRun(*runID, results, err)
+}
+
+// resumeCommand continues a run from its first unsuccessful step.
+func resumeCommand(args []string) error {
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	rf := addRunnerFlags(fs)
+	runID := fs.String("run-id", "", "run to resume (required)")
+	fs.Parse(args)
+
+	if *runID == "" {
+		return fmt.Errorf("-run-id is required")
+	}
+	process, err := rf.loadProcess()
+	if err != nil {
+		return err
+	}
+	ctx, abort, stop := interruptContext()
+	defer stop()
+	results, err := rf.newRunner(abort).Resume(ctx, *runID, process)
+	return reportRun(*runID, results, err)
+}
+
+// rerunCommand re-executes a run starting from the given step.
+func rerunCommand(args []string) error {
+	fs := flag.NewFlagSet("rerun", flag.ExitOnError)
+	rf := addRunnerFlags(fs)
+	runID := fs.String("run-id", "", "run to re-execute (required)")
+	from := fs.Int("from", 0, "step ID to restart from (required)")
+	fs.Parse(args)
+
+	if *runID == "" || *from == 0 {
+		return fmt.Errorf("-run-id and -from are required")
+	}
+	process, err := rf.loadProcess()
+	if err != nil {
+		return err
+	}
+	ctx, abort, stop := interruptContext()
+	defer stop()
+	results, err := rf.newRunner(abort).Rerun(ctx, *runID, process, *from)
+	return reportRun(*runID, results, err)
+}
+
+// loadCollection loads every given process file with loadProcess into a collection;
+// without files it contains only the built-in DemoProcess.
+func loadCollection(files []string, udfs generator.UDFRegistry) (*model.ProcessCollection, error) {
+	return collect(files, func(file string) (*model.DemoProcess, error) { return loadProcess(file, udfs) })
+}
+
+// readCollection is loadCollection without the rewrites, for commands analysing the
+// processes as written.
+func readCollection(files []string) (*model.ProcessCollection, error) {
+	return collect(files, readProcess)
+}
+
+func collect(files []string, load func(string) (*model.DemoProcess, error)) (*model.ProcessCollection, error) {
+	collection := &model.ProcessCollection{Name: "Data Warehouse ETL Jobs"}
+	if len(files) == 0 {
+		files = []string{""}
+	}
+	for _, file := range files {
+		process, err := load(file)
+		if err != nil {
+			return nil, err
+		}
+		collection.Processes = append(collection.Processes, *process)
+	}
+	return collection, nil
+}
+
+// datasourceFlag collects repeated -datasource name=id flags.
+type datasourceFlag map[string]int
+
+func (f datasourceFlag) String() string { return fmt.Sprint(map[string]int(f)) }
+
+func (f datasourceFlag) Set(value string) error {
+	name, id, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected name=id, got %q", value)
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return fmt.Errorf("datasource %s: invalid ID %q", name, id)
+	}
+	f[strings.TrimSpace(name)] = n
+	return nil
+}
+
+// exportCommand writes DolphinScheduler workflow definitions for the given process files,
+// ordered so that upstream processes come first and wired together with dependent tasks.
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("out", "", "output file (default: stdout)")
+	load := fs.String("load", string(model.IncrementalLoad), "load type to export (初始化 or 增量)")
+	projectCode := fs.Int64("project-code", 0, "DolphinScheduler project code")
+	hiveDS := fs.Int("hive-datasource", 0, "DolphinScheduler datasource ID for Hive")
+	dmDS := fs.Int("dm-datasource", 0, "DolphinScheduler datasource ID for Dameng")
+	workerGroup := fs.String("worker-group", "default", "DolphinScheduler worker group")
+	datasources := datasourceFlag{}
+	fs.Var(datasources, "datasource", "`name=id` mapping a step @datasource to a DolphinScheduler datasource ID (repeatable)")
+	udfsFile := addUDFsFlag(fs)
+	fs.Parse(args)
+
+	udfs, err := readUDFs(*udfsFile)
+	if err != nil {
+		return err
+	}
+	collection, err := loadCollection(fs.Args(), udfs)
+	if err != nil {
+		return err
+	}
+	workflows, err := scheduler.ExportCollection(collection, scheduler.Options{
+		ProjectCode:    *projectCode,
+		HiveDatasource: *hiveDS,
+		DMDatasource:   *dmDS,
+		WorkerGroup:    *workerGroup,
+		Datasources:    datasources,
+		Load:           model.LoadType(*load),
+	})
+	if err != nil {
+		return err
+	}
+
+	deps := collection.Dependencies()
+	for i, wf := range workflows {
+		fmt.Fprintf(os.Stderr, "%d. %s <- %v\n", i+1, wf.ProcessDefinition.Name, deps[wf.ProcessDefinition.Name])
+	}
+
+	data, err := json.MarshalIndent(workflows, "", "  ")
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return os.WriteFile(*out, data, 0o644)
+}
+
+// validateCommand checks the given process files (or the built-in DemoProcess), as
+// written, for inconsistencies between steps, stored procedure calls not matching their
+// signatures and, with -udfs, calls to unknown functions,
+// optionally also checking the 字段逻辑 of a tables.txt, and exits with an error when any
+// are found.
+func validateCommand(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	appColumnsFile := fs.String("app-columns", "", "file listing APP table columns as `TABLE: COL1, COL2, ...`")
+	catalogFile := fs.String("catalog", "", "partition catalog; fail when a partitioned table is read without a partition filter")
+	udfsFile := fs.String("udfs", "", "custom function registry; fail when Hive steps call functions that are neither built-in nor registered")
+	tablesFile := fs.String("tables", "", "tables.txt whose 字段逻辑 expressions are checked for unknown functions (requires -udfs)")
+	fs.Parse(args)
+
+	var appColumns map[string][]string
+	if *appColumnsFile != "" {
+		var err error
+		if appColumns, err = readAppColumns(*appColumnsFile); err != nil {
+			return err
+		}
+	}
+	var catalog generator.PartitionCatalog
+	if *catalogFile != "" {
+		var err error
+		if catalog, err = readCatalog(*catalogFile); err != nil {
+			return err
+		}
+	}
+	udfs, err := readUDFs(*udfsFile)
+	if err != nil {
+		return err
+	}
+
+	if *tablesFile != "" && udfs == nil {
+		return fmt.Errorf("-tables requires -udfs")
+	}
+
+	total := 0
+	if *tablesFile != "" {
+		tables, err := readTables(*tablesFile)
+		if err != nil {
+			return err
+		}
+		for _, message := range validateFieldLogic(tables, udfs) {
+			fmt.Printf("%s: %s\n", *tablesFile, message)
+			total++
+		}
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{""}
+	}
+	for _, file := range files {
+		process, err := readProcess(file)
+		if err != nil {
+			return err
+		}
+		issues := validateProcess(process, appColumns)
+		issues = append(issues, validateProcedures(process)...)
+		if udfs != nil {
+			issues = append(issues, validateFunctions(process, udfs)...)
+		}
+		if catalog != nil {
+			issues = append(issues, validatePartitions(process, catalog)...)
+		}
+		for _, issue := range issues {
+			fmt.Printf("%s: %s\n", process.Name, issue)
+		}
+		total += len(issues)
+	}
+	if total > 0 {
+		return fmt.Errorf("%d inconsistencies found", total)
+	}
+	fmt.Println("ok")
+	return nil
+}
+
+// writeCommand writes a process (by default the built-in DemoProcess) in the demo.txt
+// format, after checking that the text parses back to the same steps.
+func writeCommand(args []string) error {
+	fs := flag.NewFlagSet("write", flag.ExitOnError)
+	file := fs.String("file", "", "demo.txt style process file (default: built-in DemoProcess)")
+	out := fs.String("out", "", "output file (default: stdout)")
+	fs.Parse(args)
+
+	process, err := readProcess(*file)
+	if err != nil {
+		return err
+	}
+	if err := parser.CheckRoundTrip(process); err != nil {
+		return err
+	}
+	text, err := parser.WriteDemoFile(process)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Print(text)
+		return nil
+	}
+	return os.WriteFile(*out, []byte(text), 0o644)
+}
+
+// importSQLCommand parses the Hive SQL steps of a process into the structured
+// HiveInitializationSQL/HiveIncrementalSQL models and prints them as JSON. Statements
+// writing a partition are imported as incremental SQL.
+// With -tables the steps are printed as tables.txt DWS table blocks instead.
+func importSQLCommand(args []string) error {
+	fs := flag.NewFlagSet("import-sql", flag.ExitOnError)
+	file := fs.String("file", "", "demo.txt style process file (default: built-in DemoProcess)")
+	stepID := fs.Int("step", 0, "only import this step (default: every insert overwrite step)")
+	tables := fs.Bool("tables", false, "print the steps as tables.txt DWS table blocks")
+	remark := fs.String("remark", "", "table remark for -tables, e.g. 统计，增量(EX_DATE)，近2月 (default: inferred from the where clause)")
+	fs.Parse(args)
+
+	process, err := readProcess(*file)
+	if err != nil {
+		return err
+	}
+	if *tables {
+		return importTables(process, *stepID, *remark)
+	}
+
+	type imported struct {
+		Step   int         `json:"step"`
+		Kind   string      `json:"kind"`
+		Config interface{} `json:"config"`
+	}
+	var results []imported
+	for _, s := range process.Steps {
+		if *stepID != 0 && s.ID != *stepID {
+			continue
+		}
+		if *stepID == 0 && !isInsertOverwrite(s) {
+			continue
+		}
+		parsed, err := parser.ParseHiveSQL(s.Content)
+		if err != nil {
+			return fmt.Errorf("step %d: %w", s.ID, err)
+		}
+		if parsed.PartitionClause != "" {
+			config, err := parser.ParseHiveIncrementalSQL(s.Content)
+			if err != nil {
+				return fmt.Errorf("step %d: %w", s.ID, err)
+			}
+			results = append(results, imported{Step: s.ID, Kind: "incremental", Config: config})
+		} else {
+			config, err := parser.ParseHiveInitializationSQL(s.Content)
+			if err != nil {
+				return fmt.Errorf("step %d: %w", s.ID, err)
+			}
+			results = append(results, imported{Step: s.ID, Kind: "initialization", Config: config})
+		}
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no Hive insert overwrite steps found")
+	}
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// isInsertOverwrite reports whether a step is a Hive insert overwrite table statement.
+func isInsertOverwrite(s model.Step) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(s.Content)), "insert overwrite table")
+}
+
+// importTables prints the insert overwrite steps of a process as tables.txt DWS table
+// blocks. The load policy comes from remark when given, otherwise it is inferred from
+// each statement's where clause; fields that could not be described are reported on stderr.
+func importTables(process *model.DemoProcess, stepID int, remark string) error {
+	var policy *parser.LoadPolicy
+	if remark != "" {
+		p, err := parser.ParseRemark(remark)
+		if err != nil {
+			return err
+		}
+		policy = p
+	}
+
+	var tables []*parser.DwsTable
+	for _, s := range process.Steps {
+		if stepID != 0 && s.ID != stepID {
+			continue
+		}
+		if stepID == 0 && !isInsertOverwrite(s) {
+			continue
+		}
+		parsed, err := parser.ParseHiveSQL(s.Content)
+		if err != nil {
+			return fmt.Errorf("step %d: %w", s.ID, err)
+		}
+		p := policy
+		if p == nil {
+			p = parser.InferLoadPolicy(parsed.WhereClause)
+		}
+		table, warnings := parser.DwsTableFromSQL(parsed.Initialization(), p)
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "step %d: %s\n", s.ID, w)
+		}
+		tables = append(tables, table)
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("no Hive insert overwrite steps found")
+	}
+	fmt.Print(parser.WriteTablesFile(tables))
+	return nil
+}
+
+// readTables parses a tables.txt spec file.
+func readTables(file string) ([]*parser.DwsTable, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseTablesFile(string(content))
+}
+
+// buildLineage builds the column lineage of the DWS tables in tablesFile through the
+// export and Sqoop steps of the given process files (or the built-in DemoProcess).
+func buildLineage(tablesFile string, files []string) (*lineage.Graph, *model.ProcessCollection, error) {
+	tables, err := readTables(tablesFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	collection, err := readCollection(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lineage.Build(tables, collection.Processes), collection, nil
+}
+
+// lineageCommand prints the column-level lineage (APP column <- DWS column <- source
+// column) as JSON or Graphviz DOT.
+func lineageCommand(args []string) error {
+	fs := flag.NewFlagSet("lineage", flag.ExitOnError)
+	tablesFile := fs.String("tables", "tables.txt", "tables.txt spec file")
+	format := fs.String("format", "json", "output format: json or dot")
+	out := fs.String("out", "", "output file (default: stdout)")
+	fs.Parse(args)
+
+	graph, _, err := buildLineage(*tablesFile, fs.Args())
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch *format {
+	case "json":
+		if data, err = graph.JSON(); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	case "dot":
+		data = []byte(graph.DOT())
+	default:
+		return fmt.Errorf("unknown format %q (json or dot)", *format)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0o644)
+}
+
+// impactCommand lists the DWS/APP fields, processes and steps affected when a source
+// column is renamed or dropped.
+func impactCommand(args []string) error {
+	fs := flag.NewFlagSet("impact", flag.ExitOnError)
+	tablesFile := fs.String("tables", "tables.txt", "tables.txt spec file")
+	column := fs.String("column", "", "changed source column as `TABLE.COLUMN`, e.g. T_DWD_TS_TICKING_FACT.SEG_PRICE_TPM")
+	asJSON := fs.Bool("json", false, "print the impact as JSON")
+	fs.Parse(args)
+
+	dot := strings.LastIndex(*column, ".")
+	if dot <= 0 || dot == len(*column)-1 {
+		return fmt.Errorf("-column must be TABLE.COLUMN, got %q", *column)
+	}
+	graph, collection, err := buildLineage(*tablesFile, fs.Args())
+	if err != nil {
+		return err
+	}
+	impact, err := graph.Impact((*column)[:dot], (*column)[dot+1:], collection.Processes)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(impact, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Printf("%s affects %d fields in %d tables, %d steps in %d processes\n",
+		impact.Column, len(impact.Fields), len(impact.Tables), len(impact.Steps), len(impact.Processes))
+	if len(impact.Fields) > 0 {
+		fmt.Println("\nfields:")
+		for _, f := range impact.Fields {
+			line := fmt.Sprintf("  [%s] %s <- %s", f.Column.Layer, f.Column, f.From)
+			if f.Logic != "" {
+				line += "  (" + f.Logic + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+	process := ""
+	for _, s := range impact.Steps {
+		if s.Process != process {
+			process = s.Process
+			fmt.Printf("\nprocess %s:\n", process)
+		}
+		fmt.Printf("  %d. %s（%s）: %s\n", s.ID, s.Name, s.Load, s.Reason)
+	}
+	return nil
+}
+
+// graphCommand renders the given process files (or the built-in DemoProcess) as a Mermaid
+// or DOT workflow graph. Staging directories are shown as written in the files, before
+// the run-scoped rewrite applied when running.
+func graphCommand(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "mermaid", "output format: mermaid or dot")
+	load := fs.String("load", "", "only draw steps of this load type (初始化 or 增量)")
+	out := fs.String("out", "", "output file (default: stdout)")
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{""}
+	}
+	collection := &model.ProcessCollection{Name: "Data Warehouse ETL Jobs"}
+	for _, file := range files {
+		process, err := readProcess(file)
+		if err != nil {
+			return err
+		}
+		collection.Processes = append(collection.Processes, *process)
+	}
+	var d *diagram.Diagram
+	if len(collection.Processes) == 1 {
+		d = diagram.FromProcess(&collection.Processes[0], model.LoadType(*load))
+	} else {
+		d = diagram.FromCollection(collection, model.LoadType(*load))
+	}
+
+	var text string
+	switch *format {
+	case "mermaid":
+		text = d.Mermaid()
+	case "dot":
+		text = d.DOT()
+	default:
+		return fmt.Errorf("unknown format %q (mermaid or dot)", *format)
+	}
+	if *out == "" {
+		fmt.Print(text)
+		return nil
+	}
+	return os.WriteFile(*out, []byte(text), 0o644)
+}
+
+// lintCommand runs the Hive SQL linter over the Hive steps of the given process files (or
+// the built-in DemoProcess), or with -tables over the SQL generated from a tables.txt spec.
+// It fails when any error-level finding is reported.
+func lintCommand(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	tablesFile := fs.String("tables", "", "lint the SQL generated from this tables.txt spec instead of process files")
+	catalogFile := fs.String("catalog", "", "partition catalog; only its tables are checked for partition filters, and -tables SQL gets them added")
+	rules := fs.Bool("rules", false, "list the lint rules and exit")
+	fs.Parse(args)
+
+	if *rules {
+		for _, r := range lint.Rules {
+			fmt.Printf("%s  %-7s  %s\n", r.ID, r.Severity, r.Description)
+		}
+		return nil
+	}
+
+	var catalog generator.PartitionCatalog
+	if *catalogFile != "" {
+		var err error
+		if catalog, err = readCatalog(*catalogFile); err != nil {
+			return err
+		}
+	}
+
+	type script struct {
+		name, content string
+		load          model.LoadType
+		periodParam   string
+	}
+	var scripts []script
+	if *tablesFile != "" {
+		tables, err := readTables(*tablesFile)
+		if err != nil {
+			return err
+		}
+		for _, t := range tables {
+			init := t.ToHiveSQLConfig()
+			if init == nil {
+				fmt.Fprintf(os.Stderr, "warning: %s has no source table, skipped\n", t.Name)
+				continue
+			}
+			init.AddPartitionFilters(catalog, parser.DefaultPeriodParam)
+			scripts = append(scripts, script{t.Name + " (初始化)", init.GenerateSQL(), model.InitializationLoad, parser.DefaultPeriodParam})
+			if incr, err := t.ToHiveIncrementalSQLConfig(); err == nil {
+				incr.AddPartitionFilters(catalog, parser.DefaultPeriodParam)
+				scripts = append(scripts, script{t.Name + " (增量)", incr.Generate(), model.IncrementalLoad, parser.DefaultPeriodParam})
+			}
+		}
+	} else {
+		files := fs.Args()
+		if len(files) == 0 {
+			files = []string{""}
+		}
+		for _, file := range files {
+			process, err := readProcess(file)
+			if err != nil {
+				return err
+			}
+			periodParam := params.Declared(process)[0].Name
+			for _, s := range process.Steps {
+				if s.ResolvedCommandType() == model.HiveSQLCommand {
+					scripts = append(scripts, script{fmt.Sprintf("%s step %d", process.Name, s.ID), s.Content, s.Load, periodParam})
+				}
+			}
+		}
+	}
+
+	var errors, warnings int
+	for _, s := range scripts {
+		for _, f := range lint.LintWithCatalog(s.content, catalog, s.load, s.periodParam) {
+			fmt.Printf("%s:%s\n", s.name, f)
+			if f.Severity == lint.Error {
+				errors++
+			} else {
+				warnings++
+			}
+		}
+	}
+	if errors > 0 {
+		return fmt.Errorf("%d errors, %d warnings", errors, warnings)
+	}
+	fmt.Printf("ok (%d warnings)\n", warnings)
+	return nil
+}
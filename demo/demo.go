@@ -1,5 +1,10 @@
 package main
 
+import (
+	"demo/generator"
+	"demo/model"
+)
+
 // TableName represents a SQL table with its schema, name, and alias.
 type TableName struct {
 	Schema string
@@ -32,18 +37,24 @@ type HiveSQLScript struct {
 
 // Step represents a single, individual action within an ETL process.
 // The Script field can hold either a simple string or a structured object like HiveSQLScript.
+// DependsOn lists the IDs of steps (of the same Type) that must finish before this one starts.
+// Policy overrides the retry/timeout defaults of the step's CommandType (see model.DefaultPolicy).
 type Step struct {
 	ID          int
 	Name        string
 	Type        string // e.g., "initialization", "incremental"
 	CommandType string // e.g., "hivesql", "shell", "dm_proc"
+	DependsOn   []int
+	Policy      *model.RetryPolicy
 	Script      interface{}
 }
 
 // ETLProcess represents a complete sequence of steps for a single ETL job.
+// Params declares the run parameters (e.g. ${mt1}) used by the step scripts.
 type ETLProcess struct {
-	Name  string
-	Steps []Step
+	Name   string
+	Params []model.RunParam
+	Steps  []Step
 }
 
 // ETLBatch represents a collection of multiple ETL processes.
@@ -54,6 +65,9 @@ type ETLBatch struct {
 // DemoProcess is the specific ETL process defined in demo.txt.
 var DemoProcess = ETLProcess{
 	Name: "T_DWS_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR",
+	Params: []model.RunParam{
+		{Name: "mt1", Granularity: model.MonthGranularity, Offset: -1},
+	},
 	Steps: []Step{
 		{
 			ID:          1,
@@ -162,6 +176,7 @@ s.SALE_DATE
 			Name:        "hive intermediate temp file",
 			Type:        "initialization",
 			CommandType: "hivesql",
+			DependsOn:   []int{1},
 			Script: `SET hive.exec.compress.output=true;
 SET mapreduce.output.fileoutputformat.compress.codec=org.apache.hadoop.io.compress.SnappyCodec;
 SET mapreduce.output.fileoutputformat.compress.type=BLOCK;
@@ -198,6 +213,7 @@ FROM DWS.T_DWS_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR`,
 			Name:        "hive intermediate temp file",
 			Type:        "incremental",
 			CommandType: "hivesql",
+			DependsOn:   []int{2},
 			Script: `SET hive.exec.compress.output=true;
 SET mapreduce.output.fileoutputformat.compress.codec=org.apache.hadoop.io.compress.SnappyCodec;
 SET mapreduce.output.fileoutputformat.compress.type=BLOCK;
@@ -236,20 +252,21 @@ WHERE dt = '${mt1}' and DATA_MONTH<='${mt1}'
 			Name:        "create dameng intermediate table",
 			Type:        "initialization",
 			CommandType: "dm_proc",
-			Script:      `p_create_mid_app('T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR',null);`,
+			Script:      &generator.CreateMidApp{TargetTable: "T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR"},
 		},
 		{
 			ID:          6,
 			Name:        "create dameng intermediate table",
 			Type:        "incremental",
 			CommandType: "dm_proc",
-			Script:      `p_create_mid_app('T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR',null);`,
+			Script:      &generator.CreateMidApp{TargetTable: "T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR"},
 		},
 		{
 			ID:          7,
 			Name:        "data load into dameng temp table",
 			Type:        "initialization",
 			CommandType: "shell",
+			DependsOn:   []int{3, 5},
 			Script: `/usr/bch/3.3.0/sqoop/bin/sqoop export \
 --options-file /usr/bch/3.3.0/sqoop/conf/dm8_pro.props \
 --table MID_T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR \
@@ -262,6 +279,7 @@ WHERE dt = '${mt1}' and DATA_MONTH<='${mt1}'
 			Name:        "data load into dameng temp table",
 			Type:        "incremental",
 			CommandType: "shell",
+			DependsOn:   []int{4, 6},
 			Script: `/usr/bch/3.3.0/sqoop/bin/sqoop export \
 --options-file /usr/bch/3.3.0/sqoop/conf/dm8_pro.props \
 --table MID_T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR \
@@ -274,28 +292,42 @@ WHERE dt = '${mt1}' and DATA_MONTH<='${mt1}'
 			Name:        "replace dameng target table",
 			Type:        "initialization",
 			CommandType: "dm_proc",
-			Script:      `p_replace_tgttable('T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR','DF','DATA_MONTH',NULL,NULL,null);`,
+			DependsOn:   []int{7},
+			Script: &generator.ReplaceTargetTable{
+				TargetTable:     "T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR",
+				Mode:            generator.FullReplace,
+				PartitionColumn: "DATA_MONTH",
+			},
 		},
 		{
 			ID:          10,
 			Name:        "replace dameng target table",
 			Type:        "incremental",
 			CommandType: "dm_proc",
-			Script:      `p_replace_tgttable('T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR','DI','DATA_MONTH','${mt1}',1,null);`,
+			DependsOn:   []int{8},
+			Script: &generator.ReplaceTargetTable{
+				TargetTable:     "T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR",
+				Mode:            generator.IncrementalReplace,
+				PartitionColumn: "DATA_MONTH",
+				PeriodParam:     "mt1",
+				Retention:       1,
+			},
 		},
 		{
 			ID:          11,
 			Name:        "delete hive intermediate temp file",
 			Type:        "initialization",
 			CommandType: "shell",
-			Script:      `hdfs dfs -rm -r -f /tmp/hive/hive/T_DWS_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR`,
+			DependsOn:   []int{7},
+			Script:      generator.NewHdfsRemove("/tmp/hive/hive/T_DWS_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR"),
 		},
 		{
 			ID:          12,
 			Name:        "delete hive intermediate temp file",
 			Type:        "incremental",
 			CommandType: "shell",
-			Script:      `hdfs dfs -rm -r -f /tmp/hive/hive/T_DWS_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR`,
+			DependsOn:   []int{8},
+			Script:      generator.NewHdfsRemove("/tmp/hive/hive/T_DWS_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR"),
 		},
 	},
 }
@@ -0,0 +1,128 @@
+package diagram
+
+import (
+	"fmt"
+
+	"demo/model"
+)
+
+// EdgeKind 区分步骤之间的依赖和步骤对数据对象的读写。
+type EdgeKind string
+
+const (
+	DependencyEdge EdgeKind = "dependency"
+	DataEdge       EdgeKind = "data"
+)
+
+// Node 是图中的一个节点：步骤或数据对象。
+type Node struct {
+	ID    string
+	Label string
+	// Kind 为数据对象的类型，步骤节点为空。
+	Kind model.DataObjectKind
+}
+
+// LoadGroup 是流程中同一加载类型的步骤。
+type LoadGroup struct {
+	ID    string
+	Load  model.LoadType
+	Steps []Node
+}
+
+// ProcessGroup 是一个流程的步骤，按加载类型分组。
+type ProcessGroup struct {
+	ID    string
+	Name  string
+	Loads []LoadGroup
+}
+
+// Edge 是图中的一条边，Label 为数据边上的读写操作。
+type Edge struct {
+	From  string
+	To    string
+	Kind  EdgeKind
+	Label string
+}
+
+// Diagram 是一组流程的工作流图：步骤按流程和加载类型分组，数据对象由所有流程共享，
+// 因此一个流程写入、另一个流程读取的表会把两个流程连起来。
+type Diagram struct {
+	Name      string
+	Processes []ProcessGroup
+	Objects   []Node
+	Edges     []Edge
+
+	objects map[string]string
+}
+
+// FromProcess 返回单个流程的工作流图，load 不为空时只包含该加载类型的步骤。
+func FromProcess(p *model.DemoProcess, load model.LoadType) *Diagram {
+	d := &Diagram{Name: p.Name, objects: map[string]string{}}
+	d.addProcess(p, load)
+	return d
+}
+
+// FromCollection 返回流程集合的工作流图，load 不为空时只包含该加载类型的步骤。
+func FromCollection(c *model.ProcessCollection, load model.LoadType) *Diagram {
+	d := &Diagram{Name: c.Name, objects: map[string]string{}}
+	for i := range c.Processes {
+		d.addProcess(&c.Processes[i], load)
+	}
+	return d
+}
+
+func (d *Diagram) addProcess(p *model.DemoProcess, load model.LoadType) {
+	group := ProcessGroup{ID: fmt.Sprintf("p%d", len(d.Processes)), Name: p.Name}
+	stepID := func(id int) string { return fmt.Sprintf("%s_s%d", group.ID, id) }
+
+	byLoad := map[model.LoadType]int{}
+	included := map[int]bool{}
+	for _, s := range p.Steps {
+		if load != "" && s.Load != load {
+			continue
+		}
+		i, ok := byLoad[s.Load]
+		if !ok {
+			i = len(group.Loads)
+			byLoad[s.Load] = i
+			group.Loads = append(group.Loads, LoadGroup{ID: fmt.Sprintf("%s_%s", group.ID, s.Load.Code()), Load: s.Load})
+		}
+		label := fmt.Sprintf("%d. %s\n%s", s.ID, s.Name, s.ResolvedCommandType())
+		group.Loads[i].Steps = append(group.Loads[i].Steps, Node{ID: stepID(s.ID), Label: label})
+		included[s.ID] = true
+
+		for _, o := range s.DataObjects() {
+			obj := d.object(o)
+			if o.Access == model.ReadAccess {
+				d.Edges = append(d.Edges, Edge{From: obj, To: stepID(s.ID), Kind: DataEdge, Label: string(o.Access)})
+			} else {
+				d.Edges = append(d.Edges, Edge{From: stepID(s.ID), To: obj, Kind: DataEdge, Label: string(o.Access)})
+			}
+		}
+	}
+
+	deps := p.Dependencies()
+	for _, s := range p.Steps {
+		if !included[s.ID] {
+			continue
+		}
+		for _, dep := range deps[s.ID] {
+			if included[dep] {
+				d.Edges = append(d.Edges, Edge{From: stepID(dep), To: stepID(s.ID), Kind: DependencyEdge})
+			}
+		}
+	}
+	d.Processes = append(d.Processes, group)
+}
+
+// object 返回数据对象的节点 ID，同名同类型的对象只建一个节点。
+func (d *Diagram) object(o model.DataObject) string {
+	key := string(o.Kind) + ":" + o.Name
+	if id, ok := d.objects[key]; ok {
+		return id
+	}
+	id := fmt.Sprintf("o%d", len(d.Objects))
+	d.objects[key] = id
+	d.Objects = append(d.Objects, Node{ID: id, Label: fmt.Sprintf("%s\n%s", o.Kind, o.Name), Kind: o.Kind})
+	return id
+}
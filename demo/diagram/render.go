@@ -0,0 +1,112 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"demo/model"
+)
+
+// mermaidShapes 是各类数据对象在 Mermaid 中的节点形状（左右括号）：表为圆柱，HDFS 目录为平行四边形。
+var mermaidShapes = map[model.DataObjectKind][2]string{
+	model.SourceTableObject: {"[(", ")]"},
+	model.DwsTableObject:    {"[(", ")]"},
+	model.MidTableObject:    {"[(", ")]"},
+	model.AppTableObject:    {"[(", ")]"},
+	model.StagingDirObject:  {"[/", "/]"},
+}
+
+// objectColors 是各类数据对象的底色。
+var objectColors = map[model.DataObjectKind]string{
+	model.SourceTableObject: "#e0e0e0",
+	model.DwsTableObject:    "#cfe2ff",
+	model.StagingDirObject:  "#fff3cd",
+	model.MidTableObject:    "#ffe5d0",
+	model.AppTableObject:    "#d1e7dd",
+}
+
+// Mermaid 返回工作流图的 Mermaid flowchart：流程和加载类型为嵌套的子图，步骤依赖为实线，
+// 数据读写为标注了操作的虚线。
+func (d *Diagram) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, p := range d.Processes {
+		fmt.Fprintf(&sb, "  subgraph %s [%s]\n", p.ID, mermaidText(p.Name))
+		for _, l := range p.Loads {
+			fmt.Fprintf(&sb, "    subgraph %s [%s]\n", l.ID, mermaidText(string(l.Load)))
+			for _, s := range l.Steps {
+				fmt.Fprintf(&sb, "      %s[%s]\n", s.ID, mermaidText(s.Label))
+			}
+			sb.WriteString("    end\n")
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, o := range d.Objects {
+		shape := mermaidShapes[o.Kind]
+		fmt.Fprintf(&sb, "  %s%s%s%s\n", o.ID, shape[0], mermaidText(o.Label), shape[1])
+	}
+	for _, e := range d.Edges {
+		if e.Kind == DependencyEdge {
+			fmt.Fprintf(&sb, "  %s --> %s\n", e.From, e.To)
+		} else {
+			fmt.Fprintf(&sb, "  %s -. %s .-> %s\n", e.From, e.Label, e.To)
+		}
+	}
+	for _, o := range d.Objects {
+		fmt.Fprintf(&sb, "  style %s fill:%s\n", o.ID, objectColors[o.Kind])
+	}
+	return sb.String()
+}
+
+// mermaidText 返回 Mermaid 中带引号的节点文字，换行写成 <br/>。
+func mermaidText(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "\n", "<br/>")
+	return `"` + s + `"`
+}
+
+// DOT 返回工作流图的 Graphviz DOT 表示，分组和边的画法与 Mermaid 相同。
+func (d *Diagram) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph workflow {\n")
+	fmt.Fprintf(&sb, "  label=%s;\n", dotText(d.Name))
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, fontsize=10];\n")
+	for _, p := range d.Processes {
+		fmt.Fprintf(&sb, "  subgraph cluster_%s {\n", p.ID)
+		fmt.Fprintf(&sb, "    label=%s;\n", dotText(p.Name))
+		for _, l := range p.Loads {
+			fmt.Fprintf(&sb, "    subgraph cluster_%s {\n", l.ID)
+			fmt.Fprintf(&sb, "      label=%s;\n", dotText(string(l.Load)))
+			for _, s := range l.Steps {
+				fmt.Fprintf(&sb, "      %s [label=%s];\n", s.ID, dotText(s.Label))
+			}
+			sb.WriteString("    }\n")
+		}
+		sb.WriteString("  }\n")
+	}
+	for _, o := range d.Objects {
+		shape := "cylinder"
+		if o.Kind == model.StagingDirObject {
+			shape = "folder"
+		}
+		fmt.Fprintf(&sb, "  %s [label=%s, shape=%s, style=filled, fillcolor=%s];\n", o.ID, dotText(o.Label), shape, dotText(objectColors[o.Kind]))
+	}
+	for _, e := range d.Edges {
+		if e.Kind == DependencyEdge {
+			fmt.Fprintf(&sb, "  %s -> %s;\n", e.From, e.To)
+		} else {
+			fmt.Fprintf(&sb, "  %s -> %s [style=dashed, label=%s];\n", e.From, e.To, dotText(e.Label))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// dotText 返回 DOT 中带引号的字符串。
+func dotText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
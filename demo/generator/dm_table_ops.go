@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MidTablePrefix 是 p_create_mid_app 创建的中间表名前缀。
+const MidTablePrefix = "MID_"
+
+// CreateMidApp 描述用 p_create_mid_app 按目标表结构创建达梦中间表的操作。
+type CreateMidApp struct {
+	TargetTable string
+	// Options 为建表附加选项，为空时传 null。
+	Options string
+}
+
+// MidTable 返回创建出的中间表名，例如 MID_T_APP_XXX。
+func (c *CreateMidApp) MidTable() string {
+	return MidTablePrefix + c.TargetTable
+}
+
+// Call 返回对应的存储过程调用。
+func (c *CreateMidApp) Call() *StoredProcedureCall {
+	options := Null()
+	if c.Options != "" {
+		options = Str(c.Options)
+	}
+	return &StoredProcedureCall{
+		ProcedureName: CreateMidAppSignature.Name,
+		Arguments:     []ProcArg{Str(c.TargetTable), options},
+		Signature:     CreateMidAppSignature,
+	}
+}
+
+// Validate 方法检查目标表名并按签名检查生成的调用。
+func (c *CreateMidApp) Validate() error {
+	if strings.TrimSpace(c.TargetTable) == "" {
+		return fmt.Errorf("p_create_mid_app 缺少目标表名")
+	}
+	if strings.HasPrefix(strings.ToUpper(c.TargetTable), MidTablePrefix) {
+		return fmt.Errorf("p_create_mid_app 的参数应为目标表 %s，而不是中间表", c.TargetTable)
+	}
+	return c.Call().Validate()
+}
+
+// Generate 方法生成创建中间表的存储过程调用语句。
+func (c *CreateMidApp) Generate() string {
+	return c.Call().Generate()
+}
+
+// ReplaceMode 是用中间表替换目标表数据的方式。
+type ReplaceMode string
+
+const (
+	// FullReplace 用中间表替换目标表的全部数据，用于初始化加载。
+	FullReplace ReplaceMode = "DF"
+	// IncrementalReplace 只替换目标表中指定周期的数据，用于增量加载。
+	IncrementalReplace ReplaceMode = "DI"
+)
+
+// ReplaceTargetTable 描述用 p_replace_tgttable 将中间表数据替换进达梦目标表的操作。
+type ReplaceTargetTable struct {
+	TargetTable string
+	Mode        ReplaceMode
+	// PartitionColumn 为目标表中区分周期的字段，例如 DATA_MONTH。
+	PartitionColumn string
+	// PeriodParam 为增量替换的周期参数名，例如 mt1，生成为 '${mt1}'；全量替换时不使用。
+	PeriodParam string
+	// Retention 为增量替换时从 PeriodParam 开始替换的周期数；全量替换时不使用。
+	Retention int
+	// Condition 为附加的过滤条件，为空时传 null。
+	Condition string
+}
+
+// Call 返回对应的存储过程调用。
+func (r *ReplaceTargetTable) Call() *StoredProcedureCall {
+	period, retention, condition := Null(), Null(), Null()
+	if r.Mode == IncrementalReplace {
+		period = Param(r.PeriodParam)
+		retention = Num(strconv.Itoa(r.Retention))
+	}
+	if r.Condition != "" {
+		condition = Str(r.Condition)
+	}
+	return &StoredProcedureCall{
+		ProcedureName: ReplaceTgtTableSignature.Name,
+		Arguments:     []ProcArg{Str(r.TargetTable), Str(string(r.Mode)), Str(r.PartitionColumn), period, retention, condition},
+		Signature:     ReplaceTgtTableSignature,
+	}
+}
+
+// Validate 方法检查替换方式与周期参数是否匹配，并按签名检查生成的调用。
+func (r *ReplaceTargetTable) Validate() error {
+	if strings.TrimSpace(r.TargetTable) == "" {
+		return fmt.Errorf("p_replace_tgttable 缺少目标表名")
+	}
+	if strings.TrimSpace(r.PartitionColumn) == "" {
+		return fmt.Errorf("p_replace_tgttable(%s) 缺少周期字段", r.TargetTable)
+	}
+	switch r.Mode {
+	case FullReplace:
+		if r.PeriodParam != "" || r.Retention != 0 {
+			return fmt.Errorf("p_replace_tgttable(%s) 全量替换不能指定周期参数和周期数", r.TargetTable)
+		}
+	case IncrementalReplace:
+		if r.PeriodParam == "" {
+			return fmt.Errorf("p_replace_tgttable(%s) 增量替换缺少周期参数", r.TargetTable)
+		}
+		if r.Retention < 1 {
+			return fmt.Errorf("p_replace_tgttable(%s) 增量替换的周期数必须大于 0，实际为 %d", r.TargetTable, r.Retention)
+		}
+	default:
+		return fmt.Errorf("p_replace_tgttable(%s) 的替换方式 %q 无效，应为 DF 或 DI", r.TargetTable, r.Mode)
+	}
+	return r.Call().Validate()
+}
+
+// Generate 方法生成替换目标表的存储过程调用语句。
+func (r *ReplaceTargetTable) Generate() string {
+	return r.Call().Generate()
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDMTableOpsGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		op   interface {
+			Validate() error
+			Generate() string
+		}
+		want string
+	}{
+		{"create mid", &CreateMidApp{TargetTable: "T_APP_X"},
+			"p_create_mid_app('T_APP_X', null);"},
+		{"full replace", &ReplaceTargetTable{TargetTable: "T_APP_X", Mode: FullReplace, PartitionColumn: "DATA_MONTH"},
+			"p_replace_tgttable('T_APP_X', 'DF', 'DATA_MONTH', null, null, null);"},
+		{"incremental replace", &ReplaceTargetTable{TargetTable: "T_APP_X", Mode: IncrementalReplace, PartitionColumn: "DATA_MONTH", PeriodParam: "mt1", Retention: 1},
+			"p_replace_tgttable('T_APP_X', 'DI', 'DATA_MONTH', '${mt1}', 1, null);"},
+		{"condition", &ReplaceTargetTable{TargetTable: "T_APP_X", Mode: IncrementalReplace, PartitionColumn: "DATA_MONTH", PeriodParam: "mt1", Retention: 2, Condition: "AREA = 'CN'"},
+			"p_replace_tgttable('T_APP_X', 'DI', 'DATA_MONTH', '${mt1}', 2, 'AREA = ''CN''');"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.op.Generate(); got != tt.want {
+				t.Errorf("Generate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDMTableOpsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		op   interface{ Validate() error }
+		err  string
+	}{
+		{"mid table as target", &CreateMidApp{TargetTable: "MID_T_APP_X"}, "而不是中间表"},
+		{"no target", &CreateMidApp{}, "缺少目标表名"},
+		{"full with period", &ReplaceTargetTable{TargetTable: "T_APP_X", Mode: FullReplace, PartitionColumn: "DATA_MONTH", PeriodParam: "mt1"}, "全量替换不能指定周期参数"},
+		{"incremental without period", &ReplaceTargetTable{TargetTable: "T_APP_X", Mode: IncrementalReplace, PartitionColumn: "DATA_MONTH", Retention: 1}, "增量替换缺少周期参数"},
+		{"incremental without retention", &ReplaceTargetTable{TargetTable: "T_APP_X", Mode: IncrementalReplace, PartitionColumn: "DATA_MONTH", PeriodParam: "mt1"}, "周期数必须大于 0"},
+		{"unknown mode", &ReplaceTargetTable{TargetTable: "T_APP_X", Mode: "DX", PartitionColumn: "DATA_MONTH"}, "应为 DF 或 DI"},
+		{"invalid period param", &ReplaceTargetTable{TargetTable: "T_APP_X", Mode: IncrementalReplace, PartitionColumn: "DATA_MONTH", PeriodParam: "mt-1", Retention: 1}, "不是合法的参数名"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op.Validate(); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"fmt"
+
+	"demo/model"
+)
+
+// StagingDirCleanup 代表一个删除 HDFS 中间目录的 shell 命令，用于流程失败后的清理。
+type StagingDirCleanup struct {
+	DirectoryPath string
+}
+
+// Generate 方法生成删除中间目录的命令，目录不存在时不会报错。
+func (c *StagingDirCleanup) Generate() string {
+	return NewHdfsRemove(c.DirectoryPath).Generate()
+}
+
+// MidTableCleanup 代表一条清理达梦中间表的 SQL。
+type MidTableCleanup struct {
+	TableName string
+	// Truncate 为 true 时只清空数据并保留表结构，否则直接删除中间表。
+	Truncate bool
+}
+
+// Generate 方法生成清理中间表的 SQL 语句。
+func (c *MidTableCleanup) Generate() string {
+	if c.Truncate {
+		return fmt.Sprintf("TRUNCATE TABLE %s;", c.TableName)
+	}
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;", c.TableName)
+}
+
+// BuildFailureHandlers 根据流程各加载类型中用到的 HDFS 中间目录和达梦中间表，
+// 生成失败时执行的清理步骤。步骤 ID 接在流程已有的最大 ID 之后。
+func BuildFailureHandlers(process *model.DemoProcess, truncateMidTables bool) []model.Step {
+	nextID := 0
+	for _, s := range process.Steps {
+		if s.ID > nextID {
+			nextID = s.ID
+		}
+	}
+
+	var handlers []model.Step
+	for _, load := range []model.LoadType{model.InitializationLoad, model.IncrementalLoad} {
+		for _, dir := range process.StagingDirs(load) {
+			nextID++
+			cleanup := &StagingDirCleanup{DirectoryPath: dir}
+			handlers = append(handlers, model.Step{
+				ID:          nextID,
+				Name:        "失败清理hive中间临时文件",
+				Load:        load,
+				CommandType: model.ShellCommand,
+				Content:     cleanup.Generate(),
+			})
+		}
+		for _, table := range process.MidTables(load) {
+			nextID++
+			cleanup := &MidTableCleanup{TableName: table, Truncate: truncateMidTables}
+			handlers = append(handlers, model.Step{
+				ID:          nextID,
+				Name:        "失败清理达梦中间表",
+				Load:        load,
+				CommandType: model.DMProcCommand,
+				Content:     cleanup.Generate(),
+			})
+		}
+	}
+	return handlers
+}
@@ -0,0 +1,210 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// HdfsOp 是 HdfsCommand 支持的 hdfs dfs 子命令。
+type HdfsOp string
+
+const (
+	HdfsRemove   HdfsOp = "rm"
+	HdfsMkdir    HdfsOp = "mkdir"
+	HdfsTest     HdfsOp = "test"
+	HdfsChmod    HdfsOp = "chmod"
+	HdfsDiskUsed HdfsOp = "du"
+	HdfsGetMerge HdfsOp = "getmerge"
+)
+
+// HdfsCommand 代表一条 hdfs dfs 命令，例如删除中间目录或检查目录是否存在。
+type HdfsCommand struct {
+	Op   HdfsOp
+	Path string
+	// MorePaths 为 Path 之后的其余路径参数，例如 rm 的多个目录、mv 的目标目录。
+	MorePaths []string
+	// Recursive 对 rm、chmod 生成 -r/-R。
+	Recursive bool
+	// Force 对 rm 生成 -f，目录不存在时不报错。
+	Force bool
+	// Mode 为 chmod 的权限（例如 775 或 g+w），或 chown、chgrp 的属主、属组。
+	Mode string
+	// LocalPath 为 getmerge 合并到的本地文件。
+	LocalPath string
+}
+
+// NewHdfsRemove 返回递归、强制删除目录的命令，即 hdfs dfs -rm -r -f <dir>。
+func NewHdfsRemove(dir string) *HdfsCommand {
+	return &HdfsCommand{Op: HdfsRemove, Path: dir, Recursive: true, Force: true}
+}
+
+// Generate 方法生成 shell 命令字符串。
+func (c *HdfsCommand) Generate() string {
+	args := []string{"hdfs", "dfs"}
+	switch c.Op {
+	case HdfsRemove:
+		args = append(args, "-rm")
+		if c.Recursive {
+			args = append(args, "-r")
+		}
+		if c.Force {
+			args = append(args, "-f")
+		}
+		args = append(args, c.Path)
+		args = append(args, c.MorePaths...)
+	case HdfsMkdir:
+		args = append(args, "-mkdir", "-p", c.Path)
+	case HdfsTest:
+		args = append(args, "-test", "-e", c.Path)
+	case HdfsChmod:
+		args = append(args, "-chmod")
+		if c.Recursive {
+			args = append(args, "-R")
+		}
+		args = append(args, c.Mode, c.Path)
+		args = append(args, c.MorePaths...)
+	case HdfsDiskUsed:
+		args = append(args, "-du", "-s", "-h", c.Path)
+	case HdfsGetMerge:
+		args = append(args, "-getmerge", c.Path)
+		args = append(args, c.MorePaths...)
+		args = append(args, c.LocalPath)
+	default:
+		args = append(args, "-"+string(c.Op))
+		if c.Mode != "" {
+			args = append(args, c.Mode)
+		}
+		args = append(args, c.Path)
+		args = append(args, c.MorePaths...)
+	}
+	return strings.Join(args, " ")
+}
+
+// readOnlyHdfsOps 是不会删除或修改 HDFS 上已有数据的子命令，mkdir 只创建目录也算在内。
+// 不在其中的子命令（rm、rmr、rmdir、mv、chmod、chown、chgrp、truncate 以及无法识别的子命令）都按修改处理。
+var readOnlyHdfsOps = map[HdfsOp]bool{
+	HdfsMkdir: true, HdfsTest: true, HdfsDiskUsed: true, HdfsGetMerge: true,
+	"ls": true, "cat": true, "text": true, "stat": true, "count": true, "tail": true,
+}
+
+// modifies 返回命令是否会删除或修改 HDFS 上已有的数据。
+func (c *HdfsCommand) modifies() bool {
+	return !readOnlyHdfsOps[c.Op]
+}
+
+// paths 返回命令操作的全部 HDFS 路径。
+func (c *HdfsCommand) paths() []string {
+	return append([]string{c.Path}, c.MorePaths...)
+}
+
+var reChmodMode = regexp.MustCompile(`^([0-7]{3,4}|[ugoa]*[+=-][rwxXt]+(,[ugoa]*[+=-][rwxXt]+)*)$`)
+
+// HdfsSafety 限制 HDFS 命令可以操作的路径。
+type HdfsSafety struct {
+	// Roots 为允许删除或修改的根目录，路径必须位于某个根目录之下（不能是根目录本身）。
+	Roots []string
+	// MinDepth 为删除或修改的路径至少包含的层级数，例如 /tmp/hive/hive/T 为 4 层。
+	MinDepth int
+}
+
+// DefaultHdfsSafety 只允许删除或修改中间文件根目录下、至少到表一级的目录。
+func DefaultHdfsSafety() *HdfsSafety {
+	return &HdfsSafety{Roots: []string{DefaultStagingRoot}, MinDepth: 4}
+}
+
+// Check 检查命令的每个路径是否允许操作。只读命令（test、du、getmerge 等）和 mkdir 只要求绝对路径；
+// 其余命令（rm、mv、chmod 等以及无法识别的子命令）还要求每个路径位于 Roots 之下且层级不少于 MinDepth。
+func (s *HdfsSafety) Check(c *HdfsCommand) error {
+	if c.Op == HdfsChmod && !reChmodMode.MatchString(c.Mode) {
+		return fmt.Errorf("chmod: 权限 %q 无效", c.Mode)
+	}
+	if c.Op == HdfsGetMerge && c.LocalPath == "" {
+		return fmt.Errorf("getmerge: 缺少本地文件路径")
+	}
+	for _, p := range c.paths() {
+		if err := s.checkPath(c, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *HdfsSafety) checkPath(c *HdfsCommand, p string) error {
+	if !strings.HasPrefix(p, "/") {
+		return fmt.Errorf("%s: HDFS 路径 %q 必须是绝对路径", c.Op, p)
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == ".." || seg == "." {
+			return fmt.Errorf("%s: HDFS 路径 %q 不能包含 . 或 ..", c.Op, p)
+		}
+	}
+	if !c.modifies() {
+		return nil
+	}
+
+	if strings.ContainsAny(p, "*?[") {
+		return fmt.Errorf("%s: 拒绝操作包含通配符的路径 %s", c.Op, p)
+	}
+	clean := path.Clean(p)
+	if depth := strings.Count(clean, "/"); depth < s.MinDepth {
+		return fmt.Errorf("%s: 路径 %s 只有 %d 层，至少需要 %d 层", c.Op, p, depth, s.MinDepth)
+	}
+	for _, root := range s.Roots {
+		root = path.Clean(root)
+		if strings.HasPrefix(clean, root+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: 路径 %s 不在允许的目录 %s 之下", c.Op, p, strings.Join(s.Roots, ", "))
+}
+
+// Validate 方法按默认的安全规则检查命令。
+func (c *HdfsCommand) Validate() error {
+	return DefaultHdfsSafety().Check(c)
+}
+
+var reHdfsCommand = regexp.MustCompile(`\b(?:hdfs\s+dfs|hadoop\s+d?fs)\s+-(\w+)((?:\s+[^\s;&|]+)*)`)
+
+// ParseHdfsCommands 从 shell 脚本中识别出 hdfs dfs（或 hadoop fs、hadoop dfs）命令。
+// 无法识别的子命令按 Op 原样保留，Path 取第一个非选项参数，其余参数放入 MorePaths。
+func ParseHdfsCommands(script string) []*HdfsCommand {
+	var commands []*HdfsCommand
+	for _, m := range reHdfsCommand.FindAllStringSubmatch(script, -1) {
+		c := &HdfsCommand{Op: HdfsOp(m[1])}
+		var operands []string
+		for _, arg := range strings.Fields(m[2]) {
+			switch {
+			case arg == "-r" || arg == "-R":
+				c.Recursive = true
+			case arg == "-f":
+				c.Force = true
+			case strings.HasPrefix(arg, "-"):
+			default:
+				operands = append(operands, strings.Trim(arg, `'"`))
+			}
+		}
+		if (c.Op == HdfsChmod || c.Op == "chown" || c.Op == "chgrp") && len(operands) > 0 {
+			c.Mode, operands = operands[0], operands[1:]
+		}
+		if c.Op == HdfsGetMerge && len(operands) > 1 {
+			c.LocalPath, operands = operands[len(operands)-1], operands[:len(operands)-1]
+		}
+		if len(operands) > 0 {
+			c.Path, c.MorePaths = operands[0], operands[1:]
+		}
+		commands = append(commands, c)
+	}
+	return commands
+}
+
+// CheckHdfsCommands 检查脚本中的全部 HDFS 命令，返回第一个不允许的操作。
+func CheckHdfsCommands(script string, safety *HdfsSafety) error {
+	for _, c := range ParseHdfsCommands(script) {
+		if err := safety.Check(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package generator
+
+import "testing"
+
+func TestCheckHdfsCommands(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		ok     bool
+	}{
+		{"staging dir", "hdfs dfs -rm -r -f /tmp/hive/hive/T_X", true},
+		{"run-scoped staging dir", "hdfs dfs -rm -r -f /tmp/hive/hive/T_X/incr/${mt1}/${run_id}", true},
+		{"mkdir outside roots", "hdfs dfs -mkdir -p /user/hive/warehouse/dws.db/t_x", true},
+		{"test outside roots", "hadoop fs -test -e /user/hive/warehouse", true},
+		{"hadoop fs staging dir", "hadoop fs -rm -r -f /tmp/hive/hive/T_X", true},
+		{"hadoop dfs staging dir", "hadoop dfs -rm -r -f /tmp/hive/hive/T_X", true},
+		{"getmerge to local file", "hdfs dfs -getmerge /user/hive/warehouse/t_x /tmp/t_x.txt", true},
+		{"chmod staging dir", "hdfs dfs -chmod -R 775 /tmp/hive/hive/T_X", true},
+		{"mv inside staging root", "hdfs dfs -mv /tmp/hive/hive/T_X/a /tmp/hive/hive/T_X/b", true},
+
+		{"staging root itself", "hdfs dfs -rm -r -f /tmp/hive/hive", false},
+		{"second operand outside roots", "hdfs dfs -rm -r -f /tmp/hive/hive/T_X /user/hive/warehouse/dws.db", false},
+		{"rmr", "hdfs dfs -rmr /user/hive/warehouse", false},
+		{"rmdir", "hdfs dfs -rmdir /user/hive", false},
+		{"mv out of warehouse", "hdfs dfs -mv /user/hive/warehouse /trash", false},
+		{"mv target outside roots", "hdfs dfs -mv /tmp/hive/hive/T_X /user/hive/warehouse/dws.db/t_x", false},
+		{"chown", "hdfs dfs -chown -R hive:hive /user/hive/warehouse", false},
+		{"chgrp", "hdfs dfs -chgrp hadoop /user/hive/warehouse", false},
+		{"truncate", "hdfs dfs -truncate -w 1 /user/hive/warehouse/dws.db/t_x/000000_0", false},
+		{"unknown subcommand", "hdfs dfs -expunge /user/hive/warehouse", false},
+		{"wildcard", "hdfs dfs -rm -r /tmp/hive/hive/*", false},
+		{"relative path", "hdfs dfs -rm -r tmp/hive/hive/T_X", false},
+		{"dot dot", "hdfs dfs -rm -r /tmp/hive/hive/T_X/../../..", false},
+		{"invalid chmod mode", "hdfs dfs -chmod abc /tmp/hive/hive/T_X", false},
+		{"second command", "hdfs dfs -test -e /tmp/hive/hive/T_X && hdfs dfs -rm -r /user/hive/warehouse", false},
+		{"hadoop fs outside roots", "hadoop fs -rm -r /user/hive/warehouse", false},
+		{"hadoop dfs outside roots", "hadoop dfs -rm -r -skipTrash /user/hive/warehouse/dws.db", false},
+		{"full path to hadoop", "/usr/bin/hadoop dfs -rmr /user/hive", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckHdfsCommands(tt.script, DefaultHdfsSafety())
+			if tt.ok && err != nil {
+				t.Errorf("CheckHdfsCommands(%q) = %v, want nil", tt.script, err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("CheckHdfsCommands(%q) = nil, want an error", tt.script)
+			}
+		})
+	}
+}
+
+func TestParseHdfsCommandsRoundTrip(t *testing.T) {
+	for _, script := range []string{
+		"hdfs dfs -rm -r -f /tmp/hive/hive/T_X /tmp/hive/hive/T_Y",
+		"hdfs dfs -mv /tmp/hive/hive/T_X/a /tmp/hive/hive/T_X/b",
+		"hdfs dfs -chmod -R 775 /tmp/hive/hive/T_X",
+		"hdfs dfs -chown hive:hive /tmp/hive/hive/T_X",
+		"hdfs dfs -getmerge /tmp/hive/hive/T_X /tmp/t_x.txt",
+	} {
+		commands := ParseHdfsCommands(script)
+		if len(commands) != 1 {
+			t.Fatalf("ParseHdfsCommands(%q) returned %d commands, want 1", script, len(commands))
+		}
+		if got := commands[0].Generate(); got != script {
+			t.Errorf("Generate() = %q, want %q", got, script)
+		}
+	}
+}
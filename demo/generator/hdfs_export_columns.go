@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"demo/model"
+)
+
+// ExportField 是 DWS 表的一个输出字段，Type 为规格中的字段类型，例如 string、DECIMAL(22,3)。
+type ExportField struct {
+	Name string
+	Type string
+}
+
+// ExportRule 是按字段类型对导出字段做的转换规则。Match 判断规则是否适用，
+// Expression 返回导出时使用的表达式，导出列名保持为字段名。
+type ExportRule struct {
+	Name       string
+	Match      func(f ExportField) bool
+	Expression func(f ExportField) string
+}
+
+var reDecimalType = regexp.MustCompile(`(?i)^\s*decimal\s*\(\s*(\d+)\s*,\s*(\d+)\s*\)\s*$`)
+
+// DefaultExportRules 是生成 HDFS 导出脚本时默认使用的转换规则，按顺序匹配，只应用第一条匹配的规则：
+//   - string 类型且以 _DATE 结尾的字段按 yyyyMMdd 存储，导出为达梦可直接解析的 yyyy-MM-dd；
+//   - date/timestamp 类型的字段导出为 yyyy-MM-dd；
+//   - DECIMAL(p,s) 类型的字段显式 cast，保证导出的文本保留声明的精度。
+var DefaultExportRules = []ExportRule{
+	{
+		Name: "字符串日期",
+		Match: func(f ExportField) bool {
+			return strings.EqualFold(strings.TrimSpace(f.Type), "string") && strings.HasSuffix(strings.ToUpper(f.Name), "_DATE")
+		},
+		Expression: func(f ExportField) string {
+			return fmt.Sprintf("from_unixtime(unix_timestamp(%s,'yyyyMMdd'),'yyyy-MM-dd')", f.Name)
+		},
+	},
+	{
+		Name: "日期",
+		Match: func(f ExportField) bool {
+			t := strings.ToLower(strings.TrimSpace(f.Type))
+			return t == "date" || t == "timestamp"
+		},
+		Expression: func(f ExportField) string {
+			return fmt.Sprintf("date_format(%s,'yyyy-MM-dd')", f.Name)
+		},
+	},
+	{
+		Name: "小数",
+		Match: func(f ExportField) bool {
+			return reDecimalType.MatchString(f.Type)
+		},
+		Expression: func(f ExportField) string {
+			m := reDecimalType.FindStringSubmatch(f.Type)
+			return fmt.Sprintf("cast(%s as decimal(%s,%s))", f.Name, m[1], m[2])
+		},
+	},
+}
+
+// AuditColumns 是导出时加在 DWS 字段之前的审计列：导出时间和数据所属周期（分区字段 dt）。
+// DWS 表中与审计列同名的字段不再重复导出。
+var AuditColumns = []HdfsColumnMapping{
+	{Expression: "date_format(current_timestamp, 'yyyyMMddHHmmss')", Alias: "ETL_TIME"},
+	{Expression: "dt", Alias: "DATA_MONTH"},
+}
+
+// DefaultHdfsExportSettings 返回导出到 HDFS 时使用的 Hive 压缩设置。
+func DefaultHdfsExportSettings() map[string]string {
+	return map[string]string{
+		"hive.exec.compress.output":                        "true",
+		"mapreduce.output.fileoutputformat.compress.codec": "org.apache.hadoop.io.compress.SnappyCodec",
+		"mapreduce.output.fileoutputformat.compress.type":  "BLOCK",
+	}
+}
+
+// BuildExportColumns 根据 DWS 表的输出字段生成导出的 SELECT 列：先是审计列，
+// 再按字段顺序应用第一条匹配的规则，没有匹配规则的字段原样导出。
+func BuildExportColumns(fields []ExportField, rules []ExportRule) []HdfsColumnMapping {
+	columns := append([]HdfsColumnMapping(nil), AuditColumns...)
+	audit := make(map[string]struct{}, len(AuditColumns))
+	for _, c := range AuditColumns {
+		audit[strings.ToUpper(c.Alias)] = struct{}{}
+	}
+
+	for _, f := range fields {
+		if _, ok := audit[strings.ToUpper(f.Name)]; ok {
+			continue
+		}
+		column := HdfsColumnMapping{Expression: f.Name}
+		for _, rule := range rules {
+			if rule.Match(f) {
+				column = HdfsColumnMapping{Expression: rule.Expression(f), Alias: f.Name}
+				break
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// NewHiveToHdfsScript 根据 DWS 表的输出字段生成初始化加载的 HDFS 导出脚本对象，导出全表。
+func NewHiveToHdfsScript(table string, fields []ExportField, periodParam string) *HiveToHdfsScript {
+	return &HiveToHdfsScript{
+		HiveSettings:    DefaultHdfsExportSettings(),
+		DirectoryPath:   NewStagingPath(table, model.InitializationLoad, periodParam).Generate(),
+		IsRowFormatSet:  true,
+		FieldTerminator: ",",
+		SelectColumns:   BuildExportColumns(fields, DefaultExportRules),
+		FromTable:       HdfsSourceTable{Schema: "DWS", Name: table},
+	}
+}
+
+// NewHiveToHdfsIncrScript 根据 DWS 表的输出字段生成增量加载的 HDFS 导出脚本对象，
+// 只导出当前周期的分区，whereClause 为附加的过滤条件，可以为空。
+func NewHiveToHdfsIncrScript(table string, fields []ExportField, periodParam, whereClause string) *HiveToHdfsIncrScript {
+	where := fmt.Sprintf("dt = '${%s}'", periodParam)
+	if whereClause != "" {
+		where += " and " + whereClause
+	}
+
+	script := &HiveToHdfsIncrScript{
+		HiveSettings:    DefaultHdfsExportSettings(),
+		DirectoryPath:   NewStagingPath(table, model.IncrementalLoad, periodParam).Generate(),
+		IsRowFormatSet:  true,
+		FieldTerminator: ",",
+		FromTable:       IncrHdfsSourceTable{Schema: "DWS", Name: table},
+		WhereClause:     where,
+	}
+	for _, c := range BuildExportColumns(fields, DefaultExportRules) {
+		script.SelectColumns = append(script.SelectColumns, IncrHdfsColumnMapping{Expression: c.Expression, Alias: c.Alias})
+	}
+	return script
+}
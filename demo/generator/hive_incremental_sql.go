@@ -50,6 +50,9 @@ type HiveIncrementalSQL struct {
 	Joins           []IncrJoin
 	WhereClause     string
 	GroupByColumns  []IncrGroupByColumn
+	// SourcePeriods 为增量读取截至当前周期的周期数（例如近2月为 2），用于按周期分区的源表：
+	// 0 或 1 只读当前周期，负数读取截至当前周期的全部分区。
+	SourcePeriods int
 }
 
 // Generate 方法根据对象中的变量动态构建（常量化）增量 SQL 查询字符串。
@@ -108,7 +111,14 @@ func (h *HiveIncrementalSQL) Generate() string {
 		sb.WriteString("\n")
 	}
 
-	// GROUP BY 子句
+	// GROUP BY 子句，没有启用的字段时不输出（空的 group by 在 Hive 中是语法错误）
+	hasActive := false
+	for _, col := range h.GroupByColumns {
+		hasActive = hasActive || col.IsActive
+	}
+	if !hasActive {
+		return sb.String()
+	}
 	sb.WriteString("group by\n")
 	isFirstActive := true
 	for _, col := range h.GroupByColumns {
@@ -103,7 +103,14 @@ func (h *HiveInitializationSQL) GenerateSQL() string {
 		sb.WriteString("\n")
 	}
 
-	// GROUP BY clause
+	// GROUP BY clause, omitted when no column is active (an empty "group by" is invalid HiveQL)
+	hasActive := false
+	for _, col := range h.GroupByColumns {
+		hasActive = hasActive || col.IsActive
+	}
+	if !hasActive {
+		return sb.String()
+	}
 	sb.WriteString("group by\n")
 	isFirstActive := true
 	for _, col := range h.GroupByColumns {
@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"demo/model"
+)
+
+// PartitionKind 是分区表的分区方式，决定读取时使用的分区条件。
+type PartitionKind string
+
+const (
+	// SnapshotPartition 表示每个分区都是全量快照（例如维表），读取最新分区：dt=max_pt('schema','table')。
+	SnapshotPartition PartitionKind = "snapshot"
+	// PeriodicPartition 表示每个分区只有一个周期的数据（例如事实表），增量读取加载窗口内的周期
+	// （只读当前周期时为 dt='${mt1}'），初始化读取截至当前周期的全部分区：dt<='${mt1}'。
+	PeriodicPartition PartitionKind = "periodic"
+)
+
+// TablePartition 是目录中一张分区表的元数据。
+type TablePartition struct {
+	Schema string
+	Table  string
+	Column string
+	Kind   PartitionKind
+}
+
+// PartitionCatalog 记录源表的分区方式，键为不带 schema 的大写表名。不在目录中的表视为非分区表。
+type PartitionCatalog map[string]TablePartition
+
+func catalogKey(table string) string {
+	if dot := strings.LastIndex(table, "."); dot >= 0 {
+		table = table[dot+1:]
+	}
+	return strings.ToUpper(strings.TrimSpace(table))
+}
+
+// Lookup 返回表的分区元数据，table 可以带 schema。
+func (c PartitionCatalog) Lookup(table string) (TablePartition, bool) {
+	p, ok := c[catalogKey(table)]
+	return p, ok
+}
+
+var reCatalogLine = regexp.MustCompile(`^(\w+)\.(\w+)\s*:\s*(\w+)\s+(\w+)$`)
+
+// ParsePartitionCatalog 解析分区目录，每行一张表，格式为 `schema.TABLE: 分区字段 snapshot|periodic`，
+// 例如 `dim.T_DIM_DATE: dt snapshot`。表名必须带 schema，max_pt 需要它。空行和以 # 开头的行被忽略。
+func ParsePartitionCatalog(content string) (PartitionCatalog, error) {
+	catalog := PartitionCatalog{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := reCatalogLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("第 %d 行: 格式应为 `schema.TABLE: 分区字段 snapshot|periodic`", n)
+		}
+		kind := PartitionKind(strings.ToLower(m[4]))
+		if kind != SnapshotPartition && kind != PeriodicPartition {
+			return nil, fmt.Errorf("第 %d 行: 分区方式 %q 无效，应为 snapshot 或 periodic", n, m[4])
+		}
+		p := TablePartition{Schema: m[1], Table: m[2], Column: m[3], Kind: kind}
+		catalog[catalogKey(p.Table)] = p
+	}
+	return catalog, scanner.Err()
+}
+
+// Predicate 返回读取该表时的分区条件，alias 为查询中的表别名（为空时不加限定），
+// load 为加载类型，periodParam 为周期参数名，例如 mt1。增量加载只读取当前周期。
+func (p TablePartition) Predicate(alias string, load model.LoadType, periodParam string) string {
+	return p.WindowPredicate(alias, load, periodParam, 1)
+}
+
+// WindowPredicate 与 Predicate 相同，但增量加载读取截至当前周期的 periods 个周期，
+// periods 为负数时读取截至当前周期的全部分区，为 0 时按 1 处理。
+func (p TablePartition) WindowPredicate(alias string, load model.LoadType, periodParam string, periods int) string {
+	column := p.Column
+	if alias != "" {
+		column = alias + "." + p.Column
+	}
+	switch {
+	case p.Kind == SnapshotPartition:
+		return fmt.Sprintf("%s=max_pt('%s','%s')", column, p.Schema, p.Table)
+	case load == model.InitializationLoad || periods < 0:
+		return fmt.Sprintf("%s<='${%s}'", column, periodParam)
+	case periods <= 1:
+		return fmt.Sprintf("%s='${%s}'", column, periodParam)
+	default:
+		return fmt.Sprintf("%s<='${%s}' and %s>=date_format(add_months(trunc(from_unixtime(unix_timestamp('${%s}','yyyyMM'),'yyyy-MM'),'MM'),%d),'yyyyMM')",
+			column, periodParam, column, periodParam, -(periods - 1))
+	}
+}
+
+// HasFilter 返回 condition 中是否已有该表分区字段的条件（alias.dt，alias 为空时为不带限定的 dt）。
+func (p TablePartition) HasFilter(alias, condition string) bool {
+	pattern := `(?i)(^|[^.\w])` + regexp.QuoteMeta(p.Column) + `\b`
+	if alias != "" {
+		pattern = `(?i)\b` + regexp.QuoteMeta(alias) + `\.` + regexp.QuoteMeta(p.Column) + `\b`
+	}
+	return regexp.MustCompile(pattern).MatchString(model.StripComments(condition))
+}
+
+// addPartitionFilters 为主表和启用的关联表补上目录中的分区条件：主表的条件加在 WHERE 最前面，
+// 关联表的条件加在 ON 之后，原有条件用括号括起来；WHERE 或 ON 中已有分区字段条件的表不再添加。
+// periods 为增量读取的周期数，见 WindowPredicate。
+func addPartitionFilters(catalog PartitionCatalog, load model.LoadType, periodParam string, periods int,
+	from Table, where *string, joins []Join) {
+	if p, ok := catalog.Lookup(from.Name); ok && !p.HasFilter(from.Alias, *where) {
+		pred := p.WindowPredicate(from.Alias, load, periodParam, periods)
+		if strings.TrimSpace(*where) == "" {
+			*where = pred
+		} else {
+			*where = pred + " and (" + *where + ")"
+		}
+	}
+	for i, j := range joins {
+		p, ok := catalog.Lookup(j.Target.Name)
+		if !ok || !j.IsActive || p.HasFilter(j.Target.Alias, j.Condition) || p.HasFilter(j.Target.Alias, *where) {
+			continue
+		}
+		joins[i].Condition = "(" + j.Condition + ") and " + p.WindowPredicate(j.Target.Alias, load, periodParam, periods)
+	}
+}
+
+// AddPartitionFilters 按分区目录为查询读取的分区表补上分区条件，避免全表扫描。
+func (h *HiveInitializationSQL) AddPartitionFilters(catalog PartitionCatalog, periodParam string) {
+	addPartitionFilters(catalog, model.InitializationLoad, periodParam, 0, h.FromTable, &h.WhereClause, h.Joins)
+}
+
+// AddPartitionFilters 按分区目录为增量查询读取的分区表补上分区条件，避免全表扫描；
+// 按周期分区的表读取 SourcePeriods 个周期。
+func (h *HiveIncrementalSQL) AddPartitionFilters(catalog PartitionCatalog, periodParam string) {
+	joins := make([]Join, len(h.Joins))
+	for i, j := range h.Joins {
+		joins[i] = Join{Type: j.Type, Target: Table(j.Target), Condition: j.Condition, IsActive: j.IsActive}
+	}
+	addPartitionFilters(catalog, model.IncrementalLoad, periodParam, h.SourcePeriods, Table(h.FromTable), &h.WhereClause, joins)
+	for i := range h.Joins {
+		h.Joins[i].Condition = joins[i].Condition
+	}
+}
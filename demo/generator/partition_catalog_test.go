@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"demo/model"
+)
+
+func testCatalog(t *testing.T) PartitionCatalog {
+	t.Helper()
+	catalog, err := ParsePartitionCatalog("dwd.T_FACT: dt periodic\ndim.T_DIM: dt snapshot\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return catalog
+}
+
+func TestWindowPredicate(t *testing.T) {
+	catalog := testCatalog(t)
+	fact, _ := catalog.Lookup("dwd.T_FACT")
+	dim, _ := catalog.Lookup("T_DIM")
+	tests := []struct {
+		name    string
+		p       TablePartition
+		load    model.LoadType
+		periods int
+		want    string
+	}{
+		{"snapshot", dim, model.IncrementalLoad, 2, "d.dt=max_pt('dim','T_DIM')"},
+		{"initialization", fact, model.InitializationLoad, 2, "s.dt<='${mt1}'"},
+		{"current period", fact, model.IncrementalLoad, 1, "s.dt='${mt1}'"},
+		{"unset window", fact, model.IncrementalLoad, 0, "s.dt='${mt1}'"},
+		{"unbounded window", fact, model.IncrementalLoad, -1, "s.dt<='${mt1}'"},
+		{"two months", fact, model.IncrementalLoad, 2,
+			"s.dt<='${mt1}' and s.dt>=date_format(add_months(trunc(from_unixtime(unix_timestamp('${mt1}','yyyyMM'),'yyyy-MM'),'MM'),-1),'yyyyMM')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alias := "s"
+			if tt.p.Kind == SnapshotPartition {
+				alias = "d"
+			}
+			if got := tt.p.WindowPredicate(alias, tt.load, "mt1", tt.periods); got != tt.want {
+				t.Errorf("WindowPredicate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPartitionFilters(t *testing.T) {
+	h := &HiveIncrementalSQL{
+		FromTable:     IncrTable{Schema: "dwd", Name: "T_FACT", Alias: "s"},
+		WhereClause:   "s.a = 1 or s.b = 2",
+		SourcePeriods: 2,
+		Joins: []IncrJoin{
+			{Type: "left join", Target: IncrTable{Schema: "dim", Name: "T_DIM", Alias: "d"}, Condition: "s.k = d.k or s.k2 = d.k", IsActive: true},
+		},
+	}
+	h.AddPartitionFilters(testCatalog(t), "mt1")
+
+	// 原有条件中的 or 必须被括起来，分区条件才能作用于整个 WHERE 和 ON。
+	if !strings.HasSuffix(h.WhereClause, " and (s.a = 1 or s.b = 2)") || !strings.HasPrefix(h.WhereClause, "s.dt<='${mt1}' and s.dt>=") {
+		t.Errorf("WhereClause = %q", h.WhereClause)
+	}
+	if want := "(s.k = d.k or s.k2 = d.k) and d.dt=max_pt('dim','T_DIM')"; h.Joins[0].Condition != want {
+		t.Errorf("join condition = %q, want %q", h.Joins[0].Condition, want)
+	}
+
+	// 已有分区条件时不再添加。
+	where, condition := h.WhereClause, h.Joins[0].Condition
+	h.AddPartitionFilters(testCatalog(t), "mt1")
+	if h.WhereClause != where || h.Joins[0].Condition != condition {
+		t.Errorf("AddPartitionFilters is not idempotent: %q, %q", h.WhereClause, h.Joins[0].Condition)
+	}
+}
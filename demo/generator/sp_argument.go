@@ -0,0 +1,294 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"demo/model"
+)
+
+// ArgKind 是存储过程参数的类别，决定参数在调用语句中的写法。
+type ArgKind int
+
+const (
+	// StringArg 是字符串字面量，生成时用单引号包围，内部的单引号转义为两个单引号。
+	StringArg ArgKind = iota
+	// NumberArg 是数字字面量，原样输出。
+	NumberArg
+	// NullArg 是 null。
+	NullArg
+	// RawArg 是原样输出的 SQL 表达式，例如 sysdate。
+	RawArg
+	// ParamArg 是运行参数占位符，生成为字符串字面量 '${name}'，运行时替换为参数值。
+	ParamArg
+)
+
+func (k ArgKind) String() string {
+	switch k {
+	case StringArg:
+		return "字符串"
+	case NumberArg:
+		return "数字"
+	case NullArg:
+		return "null"
+	case RawArg:
+		return "表达式"
+	case ParamArg:
+		return "参数"
+	default:
+		return fmt.Sprintf("ArgKind(%d)", int(k))
+	}
+}
+
+// ProcArg 是存储过程调用中的一个参数。
+type ProcArg struct {
+	Kind  ArgKind
+	Value string
+}
+
+// Str 返回字符串字面量参数。
+func Str(value string) ProcArg { return ProcArg{Kind: StringArg, Value: value} }
+
+// Num 返回数字字面量参数。
+func Num(value string) ProcArg { return ProcArg{Kind: NumberArg, Value: value} }
+
+// Null 返回 null 参数。
+func Null() ProcArg { return ProcArg{Kind: NullArg} }
+
+// Raw 返回原样输出的表达式参数。
+func Raw(expr string) ProcArg { return ProcArg{Kind: RawArg, Value: expr} }
+
+// Param 返回运行参数占位符参数，例如 Param("mt1") 生成 '${mt1}'。
+func Param(name string) ProcArg { return ProcArg{Kind: ParamArg, Value: name} }
+
+var (
+	reNumberLiteral = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	reParamName     = regexp.MustCompile(`^\w+$`)
+)
+
+// Validate 检查参数值是否符合其类别。
+func (a ProcArg) Validate() error {
+	switch a.Kind {
+	case StringArg, NullArg:
+		return nil
+	case NumberArg:
+		if !reNumberLiteral.MatchString(a.Value) {
+			return fmt.Errorf("%q 不是合法的数字", a.Value)
+		}
+	case RawArg:
+		if strings.TrimSpace(a.Value) == "" {
+			return fmt.Errorf("表达式参数不能为空")
+		}
+	case ParamArg:
+		if !reParamName.MatchString(a.Value) {
+			return fmt.Errorf("%q 不是合法的参数名", a.Value)
+		}
+	default:
+		return fmt.Errorf("未知的参数类别 %v", a.Kind)
+	}
+	return nil
+}
+
+// Generate 返回参数在调用语句中的写法。
+func (a ProcArg) Generate() string {
+	switch a.Kind {
+	case StringArg:
+		return "'" + strings.ReplaceAll(a.Value, "'", "''") + "'"
+	case NullArg:
+		return "null"
+	case ParamArg:
+		return fmt.Sprintf("'${%s}'", a.Value)
+	default:
+		return a.Value
+	}
+}
+
+// ParamType 是存储过程形参的类型。
+type ParamType string
+
+const (
+	VarcharParam ParamType = "varchar"
+	NumberParam  ParamType = "number"
+)
+
+// accepts 返回该类型的形参能否接收指定类别的实参（null 由 Nullable 单独判断）。
+func (t ParamType) accepts(k ArgKind) bool {
+	switch k {
+	case RawArg, NullArg:
+		return true
+	case NumberArg:
+		return t == NumberParam
+	case StringArg, ParamArg:
+		return t == VarcharParam
+	default:
+		return false
+	}
+}
+
+// ProcParam 是存储过程签名中的一个形参。
+type ProcParam struct {
+	Name     string
+	Type     ParamType
+	Nullable bool
+}
+
+// ProcedureSignature 是存储过程的声明，用于在生成调用语句前检查实参。
+type ProcedureSignature struct {
+	Name   string
+	Params []ProcParam
+}
+
+// Check 检查实参的个数、类别以及是否允许为 null。
+func (sig *ProcedureSignature) Check(args []ProcArg) error {
+	if len(args) != len(sig.Params) {
+		return fmt.Errorf("%s 需要 %d 个参数，实际为 %d 个", sig.Name, len(sig.Params), len(args))
+	}
+	for i, arg := range args {
+		p := sig.Params[i]
+		if err := arg.Validate(); err != nil {
+			return fmt.Errorf("%s 的参数 %s: %w", sig.Name, p.Name, err)
+		}
+		if arg.Kind == NullArg && !p.Nullable {
+			return fmt.Errorf("%s 的参数 %s 不能为 null", sig.Name, p.Name)
+		}
+		if !p.Type.accepts(arg.Kind) {
+			return fmt.Errorf("%s 的参数 %s 为 %s 类型，不能传入%s", sig.Name, p.Name, p.Type, arg.Kind)
+		}
+	}
+	return nil
+}
+
+// 达梦中已知存储过程的签名。
+var (
+	// CreateMidAppSignature 是 p_create_mid_app(目标表名, 建表附加选项)。
+	CreateMidAppSignature = &ProcedureSignature{
+		Name: "p_create_mid_app",
+		Params: []ProcParam{
+			{Name: "table_name", Type: VarcharParam},
+			{Name: "options", Type: VarcharParam, Nullable: true},
+		},
+	}
+	// ReplaceTgtTableSignature 是 p_replace_tgttable(目标表名, 替换方式 DF/DI, 周期字段, 周期, 替换的周期数, 附加条件)。
+	ReplaceTgtTableSignature = &ProcedureSignature{
+		Name: "p_replace_tgttable",
+		Params: []ProcParam{
+			{Name: "table_name", Type: VarcharParam},
+			{Name: "replace_mode", Type: VarcharParam},
+			{Name: "period_column", Type: VarcharParam},
+			{Name: "period", Type: VarcharParam, Nullable: true},
+			{Name: "retention", Type: NumberParam, Nullable: true},
+			{Name: "condition", Type: VarcharParam, Nullable: true},
+		},
+	}
+)
+
+// KnownProcedures 按名称（小写）索引已知的存储过程签名。
+var KnownProcedures = map[string]*ProcedureSignature{
+	CreateMidAppSignature.Name:    CreateMidAppSignature,
+	ReplaceTgtTableSignature.Name: ReplaceTgtTableSignature,
+}
+
+// checkProcedureCall 按签名检查调用；signature 为空时使用 KnownProcedures 中同名的签名，
+// 都没有时只检查每个参数自身是否合法。
+func checkProcedureCall(name string, signature *ProcedureSignature, args []ProcArg) error {
+	if signature == nil {
+		signature = KnownProcedures[strings.ToLower(name)]
+	}
+	if signature != nil {
+		if !strings.EqualFold(signature.Name, name) {
+			return fmt.Errorf("调用的存储过程 %s 与签名 %s 不一致", name, signature.Name)
+		}
+		return signature.Check(args)
+	}
+	for i, arg := range args {
+		if err := arg.Validate(); err != nil {
+			return fmt.Errorf("%s 的第 %d 个参数: %w", name, i+1, err)
+		}
+	}
+	return nil
+}
+
+// generateProcedureCall 生成形如 name(arg1, arg2); 的调用语句。
+func generateProcedureCall(name string, args []ProcArg) string {
+	rendered := make([]string, len(args))
+	for i, arg := range args {
+		rendered[i] = arg.Generate()
+	}
+	return fmt.Sprintf("%s(%s);", name, strings.Join(rendered, ", "))
+}
+
+var (
+	reProcedureName = regexp.MustCompile(`\b(\w+)\s*\(`)
+	reParamLiteral  = regexp.MustCompile(`^'\$\{(\w+)\}'$`)
+)
+
+// ParseProcArg 按调用语句中的写法识别参数，是 Generate 的逆操作：'${name}' 为运行参数，
+// 其他单引号字符串为字符串（其中连续两个单引号还原为一个），null 为 null，数字为数字，其余为表达式。
+func ParseProcArg(text string) ProcArg {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.EqualFold(text, "null"):
+		return Null()
+	case reParamLiteral.MatchString(text):
+		return Param(reParamLiteral.FindStringSubmatch(text)[1])
+	case len(text) >= 2 && strings.HasPrefix(text, "'") && strings.HasSuffix(text, "'"):
+		return Str(strings.ReplaceAll(text[1:len(text)-1], "''", "'"))
+	case reNumberLiteral.MatchString(text):
+		return Num(text)
+	default:
+		return Raw(text)
+	}
+}
+
+// ProcedureCalls 返回脚本中对 KnownProcedures 中存储过程的调用，参数按 ParseProcArg 识别，
+// 用于按签名检查手写的达梦脚本。括号不配对的调用被忽略。
+func ProcedureCalls(script string) []*StoredProcedureCall {
+	text := model.StripComments(script)
+	var calls []*StoredProcedureCall
+	for _, m := range reProcedureName.FindAllStringSubmatchIndex(text, -1) {
+		name := text[m[2]:m[3]]
+		signature, ok := KnownProcedures[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		args, ok := splitCallArguments(text[m[1]:])
+		if !ok {
+			continue
+		}
+		call := &StoredProcedureCall{ProcedureName: name, Signature: signature}
+		for _, arg := range args {
+			call.Arguments = append(call.Arguments, ParseProcArg(arg))
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// splitCallArguments 按顶层逗号拆分调用的实参，text 从左括号之后开始，到与之配对的右括号为止；
+// 字符串中的逗号和括号不参与拆分。没有配对的右括号时返回 false。
+func splitCallArguments(text string) ([]string, bool) {
+	var args []string
+	depth, start := 0, 0
+	quoted := false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ',' && depth == 0:
+			args = append(args, text[start:i])
+			start = i + 1
+		case c == ')' && depth == 0:
+			if last := text[start:i]; len(args) > 0 || strings.TrimSpace(last) != "" {
+				args = append(args, last)
+			}
+			return args, true
+		case c == ')':
+			depth--
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcArgGenerate(t *testing.T) {
+	tests := []struct {
+		arg  ProcArg
+		want string
+	}{
+		{Str("T_APP"), "'T_APP'"},
+		{Str("o'brien"), "'o''brien'"},
+		{Str("a''b"), "'a''''b'"},
+		{Num("1"), "1"},
+		{Null(), "null"},
+		{Raw("sysdate"), "sysdate"},
+		{Param("mt1"), "'${mt1}'"},
+	}
+	for _, tt := range tests {
+		if got := tt.arg.Generate(); got != tt.want {
+			t.Errorf("%v.Generate() = %s, want %s", tt.arg, got, tt.want)
+		}
+		// 生成的写法能识别回原来的参数。
+		if back := ParseProcArg(tt.arg.Generate()); back != tt.arg {
+			t.Errorf("ParseProcArg(%s) = %v, want %v", tt.want, back, tt.arg)
+		}
+	}
+}
+
+func TestProcedureSignatureCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		call *StoredProcedureCall
+		err  string
+	}{
+		{"valid", (&ReplaceTargetTable{TargetTable: "T_APP", Mode: IncrementalReplace, PartitionColumn: "DATA_MONTH", PeriodParam: "mt1", Retention: 1}).Call(), ""},
+		{"too few", &StoredProcedureCall{ProcedureName: "p_create_mid_app", Arguments: []ProcArg{Str("T_APP")}}, "需要 2 个参数，实际为 1 个"},
+		{"too many", &StoredProcedureCall{ProcedureName: "P_CREATE_MID_APP", Arguments: []ProcArg{Str("T_APP"), Null(), Null()}}, "需要 2 个参数，实际为 3 个"},
+		{"not nullable", &StoredProcedureCall{ProcedureName: "p_create_mid_app", Arguments: []ProcArg{Null(), Null()}}, "table_name 不能为 null"},
+		{"string for number", &StoredProcedureCall{ProcedureName: "p_replace_tgttable",
+			Arguments: []ProcArg{Str("T_APP"), Str("DI"), Str("DATA_MONTH"), Param("mt1"), Str("1"), Null()}}, "retention 为 number 类型，不能传入字符串"},
+		{"number for string", &StoredProcedureCall{ProcedureName: "p_create_mid_app", Arguments: []ProcArg{Num("1"), Null()}}, "table_name 为 varchar 类型，不能传入数字"},
+		{"invalid number", &StoredProcedureCall{ProcedureName: "p_replace_tgttable",
+			Arguments: []ProcArg{Str("T_APP"), Str("DI"), Str("DATA_MONTH"), Param("mt1"), Num("1a"), Null()}}, `"1a" 不是合法的数字`},
+		{"signature mismatch", &StoredProcedureCall{ProcedureName: "p_other", Arguments: []ProcArg{Str("T_APP"), Null()}, Signature: CreateMidAppSignature}, "与签名 p_create_mid_app 不一致"},
+		{"unknown procedure", &StoredProcedureCall{ProcedureName: "p_other", Arguments: []ProcArg{Str("x"), Num("1")}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call.Validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestProcedureCalls(t *testing.T) {
+	script := `-- p_create_mid_app('COMMENTED', null);
+p_create_mid_app('T_APP',null);
+p_replace_tgttable('T_APP','DI','DATA_MONTH','${mt1}',1,'a = ''x, y'' and f(b)');
+p_other('T_APP');`
+	calls := ProcedureCalls(script)
+	if len(calls) != 2 {
+		t.Fatalf("ProcedureCalls() returned %d calls, want 2", len(calls))
+	}
+	if want := []ProcArg{Str("T_APP"), Null()}; !reflect.DeepEqual(calls[0].Arguments, want) {
+		t.Errorf("p_create_mid_app arguments = %v, want %v", calls[0].Arguments, want)
+	}
+	want := []ProcArg{Str("T_APP"), Str("DI"), Str("DATA_MONTH"), Param("mt1"), Num("1"), Str("a = 'x, y' and f(b)")}
+	if !reflect.DeepEqual(calls[1].Arguments, want) {
+		t.Errorf("p_replace_tgttable arguments = %v, want %v", calls[1].Arguments, want)
+	}
+	for _, c := range calls {
+		if err := c.Validate(); err != nil {
+			t.Errorf("%s: %v", c.ProcedureName, err)
+		}
+	}
+}
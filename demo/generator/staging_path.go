@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"demo/model"
+)
+
+// DefaultStagingRoot 是 Hive 导出到 HDFS 的中间文件根目录。
+const DefaultStagingRoot = "/tmp/hive/hive"
+
+// StagingPath 描述一次运行专用的 HDFS 中间目录。
+// 目录中包含表名、加载类型、周期参数和运行 ID，避免初始化与增量、或不同周期的回填互相覆盖。
+type StagingPath struct {
+	Root  string
+	Table string
+	Load  model.LoadType
+	// PeriodParam 为周期参数名（例如 mt1），为空时目录中不包含周期，初始化加载通常如此。
+	PeriodParam string
+}
+
+// Generate 方法生成带占位符的目录，例如 /tmp/hive/hive/T_DWS_X/incr/${mt1}/${run_id}。
+func (s StagingPath) Generate() string {
+	root := s.Root
+	if root == "" {
+		root = DefaultStagingRoot
+	}
+	parts := []string{strings.TrimRight(root, "/"), s.Table, s.Load.Code()}
+	if s.PeriodParam != "" {
+		parts = append(parts, fmt.Sprintf("${%s}", s.PeriodParam))
+	}
+	parts = append(parts, "${run_id}")
+	return strings.Join(parts, "/")
+}
+
+// NewStagingPath 按加载类型创建中间目录：增量加载的目录包含 periodParam，初始化加载不包含。
+func NewStagingPath(table string, load model.LoadType, periodParam string) StagingPath {
+	s := StagingPath{Root: DefaultStagingRoot, Table: table, Load: load}
+	if load == model.IncrementalLoad {
+		s.PeriodParam = periodParam
+	}
+	return s
+}
+
+// ScopeStagingDirs 将流程中直接写在 root/<表名> 下的旧式共享中间目录改写为运行专用目录，
+// 导出、Sqoop 和删除步骤中的同一目录会被改写为同一个值。已经是运行专用目录的路径保持不变。
+func ScopeStagingDirs(process *model.DemoProcess, root, periodParam string) {
+	reShared := regexp.MustCompile(regexp.QuoteMeta(strings.TrimRight(root, "/")) + `/(\w+)(['"\s;]|$)`)
+	for i := range process.Steps {
+		step := &process.Steps[i]
+		path := NewStagingPath("", step.Load, periodParam)
+		path.Root = root
+		step.Content = reShared.ReplaceAllStringFunc(step.Content, func(m string) string {
+			matches := reShared.FindStringSubmatch(m)
+			path.Table = matches[1]
+			return path.Generate() + matches[2]
+		})
+	}
+}
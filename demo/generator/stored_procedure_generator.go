@@ -1,32 +1,22 @@
 package generator
 
-import (
-	"fmt"
-	"strings"
-)
-
 // StoredProcedureCall 代表一个通用的数据库存储过程调用。
 // 它可以用于表示 demo.txt 中的多个步骤。
 type StoredProcedureCall struct {
 	ProcedureName string
-	// 参数应作为字符串提供。
-	// 例如，一个字符串字面量 'my_table' 应在此处表示为 "my_table"，
-	// 而一个 NULL 值应表示为 "null"。
-	Arguments []string
+	// 参数按类别提供，例如 Str("my_table")、Num("1")、Null()、Param("mt1")。
+	Arguments []ProcArg
+	// Signature 为存储过程的声明，为空时使用 KnownProcedures 中的同名声明。
+	Signature *ProcedureSignature
+}
+
+// Validate 方法按存储过程签名检查参数的个数和类别。
+func (spc *StoredProcedureCall) Validate() error {
+	return checkProcedureCall(spc.ProcedureName, spc.Signature, spc.Arguments)
 }
 
 // Generate 方法根据对象中的变量动态构建存储过程调用的字符串。
+// 字符串参数中的单引号会被转义，生成前应先调用 Validate 检查参数。
 func (spc *StoredProcedureCall) Generate() string {
-	var processedArgs []string
-	for _, arg := range spc.Arguments {
-		// 在SQL中，'null' 是一个关键字，不应该被引号包围。
-		// 我们假定其他参数都是字符串字面量，需要用单引号包围。
-		if strings.ToLower(arg) == "null" {
-			processedArgs = append(processedArgs, arg)
-		} else {
-			processedArgs = append(processedArgs, fmt.Sprintf("'%s'", arg))
-		}
-	}
-
-	return fmt.Sprintf("%s(%s);", spc.ProcedureName, strings.Join(processedArgs, ", "))
+	return generateProcedureCall(spc.ProcedureName, spc.Arguments)
 }
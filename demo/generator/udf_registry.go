@@ -0,0 +1,205 @@
+package generator
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"demo/model"
+)
+
+// UDF 是一个需要先注册才能在 Hive 中使用的自定义函数。
+type UDF struct {
+	Name string
+	// Jar 为函数所在 jar 包的路径，通常在 HDFS 上。
+	Jar   string
+	Class string
+	// Signature 为函数的参数和返回值说明，只用于文档和提示，例如 max_pt(STRING schema, STRING table) -> STRING。
+	Signature string
+}
+
+// UDFRegistry 是自定义函数目录，键为小写的函数名。目录没有内置内容，jar 路径和类名因环境而异，
+// 需要从目录文件读取（见 ParseUDFRegistry）；不在目录中的函数（例如 max_pt）假定已在 Hive 中注册。
+type UDFRegistry map[string]UDF
+
+// Lookup 返回函数名对应的自定义函数，不区分大小写。
+func (r UDFRegistry) Lookup(name string) (UDF, bool) {
+	u, ok := r[strings.ToLower(name)]
+	return u, ok
+}
+
+var reUDFLine = regexp.MustCompile(`^(\w+)\s*:\s*(\S+)\s+(\S+)(?:\s+(.*))?$`)
+
+// ParseUDFRegistry 解析自定义函数目录，每行一个函数，格式为 `函数名: jar路径 类名 签名`，
+// 例如 `max_pt: hdfs:///path/to/udf.jar com.example.MaxPt max_pt(STRING schema, STRING table) -> STRING`。
+// 签名可以省略，空行和以 # 开头的行被忽略。
+func ParseUDFRegistry(content string) (UDFRegistry, error) {
+	registry := UDFRegistry{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := reUDFLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("第 %d 行: 格式应为 `函数名: jar路径 类名 签名`", n)
+		}
+		if IsBuiltinFunction(m[1]) {
+			return nil, fmt.Errorf("第 %d 行: %s 是 Hive 内置函数，不能注册为自定义函数", n, m[1])
+		}
+		registry[strings.ToLower(m[1])] = UDF{Name: m[1], Jar: m[2], Class: m[3], Signature: strings.TrimSpace(m[4])}
+	}
+	return registry, scanner.Err()
+}
+
+var (
+	reQuoted       = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+	reFunctionCall = regexp.MustCompile(`\b([A-Za-z_]\w*)\s*\(`)
+	reCreatedUDF   = regexp.MustCompile(`(?i)\bcreate\s+temporary\s+function\s+(\w+)`)
+	reAddedJar     = regexp.MustCompile(`(?i)\badd\s+jar\s+(\S+?)\s*;`)
+	// reNamesObject 匹配后面跟表名、视图名的关键字，例如 insert into table T_X (...) 中的 T_X 不是函数。
+	reNamesObject = regexp.MustCompile(`(?i)\b(table|view|into|exists)\s+$`)
+)
+
+// FunctionCalls 返回 SQL 中调用的函数名（小写，按首次出现排列），忽略注释、字符串常量、
+// 带限定的名称（db.func）、表名和视图名，以及后面跟括号的关键字（in、exists、having、union all 等）和类型名。
+func FunctionCalls(sql string) []string {
+	text := reQuoted.ReplaceAllString(model.StripComments(sql), "''")
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range reFunctionCall.FindAllStringSubmatchIndex(text, -1) {
+		name := strings.ToLower(text[m[2]:m[3]])
+		before := text[:m[0]]
+		if len(before) > 32 {
+			before = before[len(before)-32:]
+		}
+		if strings.HasSuffix(before, ".") || reNamesObject.MatchString(before) {
+			continue
+		}
+		if _, ok := callKeywords[name]; ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// Used 返回 SQL 中调用的已注册自定义函数，按首次出现排列。
+func (r UDFRegistry) Used(sql string) []UDF {
+	var used []UDF
+	for _, name := range FunctionCalls(sql) {
+		if u, ok := r.Lookup(name); ok {
+			used = append(used, u)
+		}
+	}
+	return used
+}
+
+// Unknown 返回 SQL 中调用的既不是 Hive 内置函数、也没有注册的函数名，按首次出现排列。
+func (r UDFRegistry) Unknown(sql string) []string {
+	var unknown []string
+	for _, name := range FunctionCalls(sql) {
+		if _, ok := r.Lookup(name); !ok && !IsBuiltinFunction(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// Preamble 返回脚本使用的自定义函数所需的 ADD JAR 和 CREATE TEMPORARY FUNCTION 语句，
+// 脚本中已经创建的函数和已经添加的 jar 不再重复。不使用自定义函数时返回空串。
+func (r UDFRegistry) Preamble(sql string) string {
+	created := map[string]bool{}
+	for _, m := range reCreatedUDF.FindAllStringSubmatch(sql, -1) {
+		created[strings.ToLower(m[1])] = true
+	}
+	added := map[string]bool{}
+	for _, m := range reAddedJar.FindAllStringSubmatch(sql, -1) {
+		added[strings.Trim(m[1], `'"`)] = true
+	}
+
+	var jars, functions []string
+	for _, u := range r.Used(sql) {
+		if created[strings.ToLower(u.Name)] {
+			continue
+		}
+		if !added[u.Jar] {
+			added[u.Jar] = true
+			jars = append(jars, fmt.Sprintf("add jar %s;", u.Jar))
+		}
+		functions = append(functions, fmt.Sprintf("create temporary function %s as '%s';", u.Name, u.Class))
+	}
+	if len(functions) == 0 {
+		return ""
+	}
+	return strings.Join(append(jars, functions...), "\n") + "\n"
+}
+
+// WithPreamble 返回在开头加上 Preamble 的脚本，不需要时原样返回。
+func (r UDFRegistry) WithPreamble(sql string) string {
+	return r.Preamble(sql) + sql
+}
+
+// AddUDFPreambles 为流程中使用自定义函数的 Hive SQL 步骤加上注册函数的语句。
+func AddUDFPreambles(process *model.DemoProcess, registry UDFRegistry) {
+	for i := range process.Steps {
+		step := &process.Steps[i]
+		if step.ResolvedCommandType() == model.HiveSQLCommand {
+			step.Content = registry.WithPreamble(step.Content)
+		}
+	}
+}
+
+// callKeywords 是后面可以直接跟括号、但不是函数的关键字和类型名。
+var callKeywords = map[string]struct{}{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		all and any as asc by case char cluster create cross decimal desc directory distinct distribute else
+		end exists from full function group having in inner insert into is join lateral left limit not on or
+		order outer over overwrite partition partitioned right rows select semi set some sort table
+		tablesample temporary then union using values varchar view when where window with
+	`) {
+		callKeywords[word] = struct{}{}
+	}
+}
+
+// builtinFunctions 是 Hive 的内置函数（小写）。
+var builtinFunctions = map[string]struct{}{}
+
+func init() {
+	for _, name := range strings.Fields(`
+		abs acos add_months aes_decrypt aes_encrypt array array_contains ascii asin atan avg base64 between bin
+		bround cast cbrt ceil ceiling char_length character_length chr coalesce collect_list collect_set
+		concat concat_ws context_ngrams conv corr cos count covar_pop covar_samp crc32 cume_dist current_database
+		current_date current_timestamp current_user date_add date_format date_sub datediff day dayofmonth
+		dayofweek decode degrees dense_rank div e elt encode exp explode extract_union factorial field
+		find_in_set first_value floor floor_day floor_hour floor_minute floor_month floor_quarter floor_second
+		floor_week floor_year format_number from_unixtime from_utc_timestamp get_json_object greatest grouping
+		hash hex histogram_numeric hour if in_file index initcap inline instr isnotnull isnull java_method
+		json_tuple lag last_day last_value lcase lead least length levenshtein like ln locate log log10 log2
+		lower lpad ltrim map map_keys map_values mask mask_first_n mask_hash mask_last_n mask_show_first_n
+		mask_show_last_n max md5 min minute mod month months_between named_struct negative next_day ngrams
+		noop nullif nvl octet_length parse_url parse_url_tuple percent_rank percentile percentile_approx pi
+		pmod posexplode positive pow power printf quarter radians rand rank reflect reflect2 regexp
+		regexp_extract regexp_replace repeat replace reverse rlike round row_number rpad rtrim second
+		sentences sha sha1 sha2 shiftleft shiftright shiftrightunsigned sign signum sin size sort_array
+		sort_array_by soundex space split sq_count_check sqrt stack std stddev stddev_pop stddev_samp str_to_map
+		struct substr substring substring_index sum tan to_date to_unix_timestamp to_utc_timestamp translate
+		trim trunc ucase unbase64 unhex unix_timestamp upper uuid var_pop var_samp variance version weekofyear
+		width_bucket xpath xpath_boolean xpath_double xpath_float xpath_int xpath_long xpath_number
+		xpath_short xpath_string year
+		string int bigint smallint tinyint double float boolean date timestamp binary
+	`) {
+		builtinFunctions[name] = struct{}{}
+	}
+}
+
+// IsBuiltinFunction 返回 name 是否为 Hive 内置函数（不区分大小写）。
+func IsBuiltinFunction(name string) bool {
+	_, ok := builtinFunctions[strings.ToLower(name)]
+	return ok
+}
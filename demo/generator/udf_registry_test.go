@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testUDFs(t *testing.T) UDFRegistry {
+	t.Helper()
+	udfs, err := ParseUDFRegistry(`
+# 测试用的自定义函数
+max_pt: hdfs:///udf/demo.jar com.example.MaxPt max_pt(STRING schema, STRING table) -> STRING
+fmt_month: hdfs:///udf/demo.jar com.example.FmtMonth
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return udfs
+}
+
+func TestUDFRegistryUnknown(t *testing.T) {
+	udfs := testUDFs(t)
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"built-in and registered", "select nvl(a, 0), MAX_PT('dwd','t'), fmt_month(b), count(1) from t", nil},
+		{"unknown", "select my_udf(a), nvl(b, 0), other_udf(c) from t", []string{"my_udf", "other_udf"}},
+		{"having", "select a, count(1) from t group by a having (count(1) > 1)", nil},
+		{"union all", "select a from t union all (select a from u)", nil},
+		{"in and exists", "select a from t where a in (1, 2) and not exists (select 1 from u)", nil},
+		{"window", "select row_number() over (partition by a order by b) from t", nil},
+		{"case", "select case (a) when 1 then 'x' else (b) end from t", nil},
+		{"cast types", "select cast(a as decimal(10,2)), cast(b as varchar(20)) from t", nil},
+		{"insert column list", "insert into table dws.t_x (a, b) select a, b from t", nil},
+		{"create if not exists", "create table if not exists t_x (a string)", nil},
+		{"qualified name", "select dws.my_udf(a) from t", nil},
+		{"comment and string", "select 'my_udf(a)' from t -- other_udf(b)", nil},
+		{"nested calls", "select nvl(my_udf(a), 0) from t", []string{"my_udf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := udfs.Unknown(tt.sql); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unknown(%q) = %v, want %v", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUDFRegistryPreamble(t *testing.T) {
+	udfs := testUDFs(t)
+	sql := "select max_pt('dwd','t'), fmt_month(a) from t"
+	want := "add jar hdfs:///udf/demo.jar;\n" +
+		"create temporary function max_pt as 'com.example.MaxPt';\n" +
+		"create temporary function fmt_month as 'com.example.FmtMonth';\n"
+	if got := udfs.Preamble(sql); got != want {
+		t.Errorf("Preamble() = %q, want %q", got, want)
+	}
+	once := udfs.WithPreamble(sql)
+	if twice := udfs.WithPreamble(once); twice != once {
+		t.Errorf("WithPreamble is not idempotent:\n%s", twice)
+	}
+	if got := udfs.Preamble("select nvl(a, 0) from t"); got != "" {
+		t.Errorf("Preamble() without custom functions = %q, want empty", got)
+	}
+	var empty UDFRegistry
+	if got := empty.WithPreamble(sql); got != sql {
+		t.Errorf("empty registry changed the script: %q", got)
+	}
+}
+
+func TestParseUDFRegistryErrors(t *testing.T) {
+	for _, content := range []string{
+		"max_pt hdfs:///udf/demo.jar",
+		"count: hdfs:///udf/demo.jar com.example.Count",
+	} {
+		if _, err := ParseUDFRegistry(content); err == nil || !strings.Contains(err.Error(), "第 1 行") {
+			t.Errorf("ParseUDFRegistry(%q) error = %v, want a line 1 error", content, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package lineage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSON 返回血缘图的 JSON 表示。
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// layerColors 是 DOT 中各数据层表的底色。
+var layerColors = map[Layer]string{
+	SourceLayer: "lightgrey",
+	DwsLayer:    "lightblue",
+	AppLayer:    "lightyellow",
+}
+
+// DOT 返回血缘图的 Graphviz DOT 表示：每张表是一个子图，按数据层从左到右排列，
+// 边上标注转换逻辑。同一对字段在多个流程中出现时只画一条边。
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph lineage {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=filled, fontsize=10];\n")
+
+	var tables []string
+	byTable := map[string][]Column{}
+	for _, c := range g.Columns {
+		if _, ok := byTable[c.Table]; !ok {
+			tables = append(tables, c.Table)
+		}
+		byTable[c.Table] = append(byTable[c.Table], c)
+	}
+	for i, table := range tables {
+		columns := byTable[table]
+		fmt.Fprintf(&sb, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&sb, "    label=%s;\n", quoteDOT(fmt.Sprintf("%s (%s)", table, columns[0].Layer)))
+		for _, c := range columns {
+			fmt.Fprintf(&sb, "    %s [label=%s, fillcolor=%s];\n", quoteDOT(c.String()), quoteDOT(c.Name), layerColors[c.Layer])
+		}
+		sb.WriteString("  }\n")
+	}
+
+	drawn := map[string]struct{}{}
+	for _, e := range g.Edges {
+		key := e.From.String() + "->" + e.To.String()
+		if _, ok := drawn[key]; ok {
+			continue
+		}
+		drawn[key] = struct{}{}
+		fmt.Fprintf(&sb, "  %s -> %s", quoteDOT(e.From.String()), quoteDOT(e.To.String()))
+		if e.Logic != "" {
+			fmt.Fprintf(&sb, " [label=%s]", quoteDOT(e.Logic))
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// quoteDOT 返回 DOT 中的带引号字符串。
+func quoteDOT(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,158 @@
+package lineage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"demo/model"
+)
+
+// AffectedField 是受变更影响的字段，From 为它依赖的已受影响字段。
+type AffectedField struct {
+	Column Column `json:"column"`
+	From   Column `json:"from"`
+	Logic  string `json:"logic,omitempty"`
+}
+
+// StepRef 是受变更影响的流程步骤，Reason 说明受影响的原因。
+type StepRef struct {
+	Process string         `json:"process"`
+	ID      int            `json:"id"`
+	Name    string         `json:"name"`
+	Load    model.LoadType `json:"load"`
+	Reason  string         `json:"reason"`
+}
+
+// Impact 是源表字段重命名或删除后受影响的字段、表、流程和步骤。
+type Impact struct {
+	Column    Column          `json:"column"`
+	Fields    []AffectedField `json:"fields"`
+	Tables    []string        `json:"tables"`
+	Processes []string        `json:"processes"`
+	Steps     []StepRef       `json:"steps"`
+}
+
+// Impact 分析源表 table 的字段 column 变更的影响：沿血缘找出全部下游字段及其所在的表，
+// 再按步骤读写的数据对象找出 processes 中受影响的步骤（见 affectedSteps）。字段既不在血缘中、也没有被任何步骤引用时返回错误。
+func (g *Graph) Impact(table, column string, processes []model.DemoProcess) (*Impact, error) {
+	root := Column{Table: tableKey(table), Name: strings.ToUpper(strings.TrimSpace(column)), Layer: SourceLayer}
+	if i, ok := g.columns[root.String()]; ok {
+		root = g.Columns[i]
+	}
+	impact := &Impact{Column: root}
+
+	// 按广度优先遍历下游字段，每个字段只记录第一次到达时的来源。
+	visited := map[string]struct{}{root.String(): {}}
+	tables := map[string]struct{}{}
+	queue := []Column{root}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, e := range g.Downstream(c) {
+			if _, ok := visited[e.To.String()]; ok {
+				continue
+			}
+			visited[e.To.String()] = struct{}{}
+			impact.Fields = append(impact.Fields, AffectedField{Column: e.To, From: c, Logic: e.Logic})
+			if _, ok := tables[e.To.Table]; !ok {
+				tables[e.To.Table] = struct{}{}
+				impact.Tables = append(impact.Tables, e.To.Table)
+			}
+			queue = append(queue, e.To)
+		}
+	}
+
+	reColumn := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(root.Name) + `\b`)
+	names := map[string]struct{}{}
+	for _, p := range processes {
+		for _, ref := range affectedSteps(p, root, reColumn, impact.Tables) {
+			impact.Steps = append(impact.Steps, ref)
+			if _, ok := names[p.Name]; !ok {
+				names[p.Name] = struct{}{}
+				impact.Processes = append(impact.Processes, p.Name)
+			}
+		}
+	}
+
+	if len(impact.Fields) == 0 && len(impact.Steps) == 0 {
+		return nil, fmt.Errorf("%s is not used by any spec or step", root)
+	}
+	sort.SliceStable(impact.Steps, func(i, j int) bool {
+		if impact.Steps[i].Process != impact.Steps[j].Process {
+			return impact.Steps[i].Process < impact.Steps[j].Process
+		}
+		return impact.Steps[i].ID < impact.Steps[j].ID
+	})
+	return impact, nil
+}
+
+// affectedSteps 按步骤的 DataObjects 找出流程中受影响的步骤：读取源表并引用源字段的步骤，
+// 读写受影响表的步骤，以及读取受影响步骤写出的 HDFS 目录或中间表的步骤（例如 Sqoop 导出）。
+// 只创建中间表或删除目录的步骤不读取受影响的数据，不算在内。
+func affectedSteps(p model.DemoProcess, root Column, reColumn *regexp.Regexp, tables []string) []StepRef {
+	affected := map[string]struct{}{}
+	for _, t := range tables {
+		affected[t] = struct{}{}
+	}
+	reasons := map[int]string{}
+	// 受影响的数据沿步骤传递到它们写出的对象，重复直到没有新的对象。
+	for changed := true; changed; {
+		changed = false
+		for _, s := range p.Steps {
+			objects := s.DataObjects()
+			reason := reasons[s.ID]
+			if reason == "" {
+				reason = stepReason(s, objects, root, reColumn, affected)
+			}
+			if reason == "" {
+				continue
+			}
+			reasons[s.ID] = reason
+			for _, o := range objects {
+				if _, ok := affected[o.Name]; !ok && o.Access == model.WriteAccess {
+					affected[o.Name] = struct{}{}
+					changed = true
+				}
+			}
+		}
+	}
+
+	var refs []StepRef
+	for _, s := range p.Steps {
+		if reason, ok := reasons[s.ID]; ok {
+			refs = append(refs, StepRef{Process: p.Name, ID: s.ID, Name: s.Name, Load: s.Load, Reason: reason})
+		}
+	}
+	return refs
+}
+
+// stepReason 返回步骤受影响的原因，不受影响时返回空串。引用源字段优先，其次是写入、读取受影响的表，
+// 最后是读取受影响数据写出的目录或中间表。
+func stepReason(s model.Step, objects []model.DataObject, root Column, reColumn *regexp.Regexp, affected map[string]struct{}) string {
+	var writes, reads, derived string
+	for _, o := range objects {
+		if o.Access == model.ReadAccess && o.Name == root.Table && reColumn.MatchString(model.StripComments(s.Content)) {
+			return "references " + root.String()
+		}
+		if _, ok := affected[o.Name]; !ok {
+			continue
+		}
+		intermediate := o.Kind == model.StagingDirObject || o.Kind == model.MidTableObject
+		switch {
+		case o.Access == model.WriteAccess && !intermediate && writes == "":
+			writes = "writes affected table " + o.Name
+		case o.Access == model.ReadAccess && !intermediate && reads == "":
+			reads = "reads affected table " + o.Name
+		case o.Access == model.ReadAccess && intermediate && derived == "":
+			derived = "reads " + o.Name + " written from affected data"
+		}
+	}
+	for _, reason := range []string{writes, reads, derived} {
+		if reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
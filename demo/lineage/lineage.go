@@ -0,0 +1,291 @@
+package lineage
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"demo/generator"
+	"demo/model"
+	"demo/parser"
+)
+
+// Layer 是字段所在的数据层。
+type Layer string
+
+const (
+	// SourceLayer 为 DWS 表读取的 DWD/DIM 表。
+	SourceLayer Layer = "source"
+	DwsLayer    Layer = "dws"
+	AppLayer    Layer = "app"
+)
+
+// PartitionColumn 是 DWS 表的分区字段，导出时作为 DATA_MONTH 写入 APP 表。
+const PartitionColumn = "DT"
+
+// Column 是某张表中的一个字段，表名不带 schema，表名和字段名均为大写。
+type Column struct {
+	Table string `json:"table"`
+	Name  string `json:"name"`
+	Layer Layer  `json:"layer"`
+}
+
+// String 返回 TABLE.COLUMN 形式的字段名。
+func (c Column) String() string {
+	return c.Table + "." + c.Name
+}
+
+// Edge 表示 To 字段由 From 字段经过 Logic 转换得到，Logic 为空表示原样传递。
+// Process 和 Steps 为产生这条血缘的流程和步骤，来自 tables.txt 规格的边没有步骤。
+type Edge struct {
+	From    Column `json:"from"`
+	To      Column `json:"to"`
+	Logic   string `json:"logic,omitempty"`
+	Process string `json:"process,omitempty"`
+	Steps   []int  `json:"steps,omitempty"`
+}
+
+// Graph 是字段级血缘图：APP 字段 ← DWS 字段 ← 源表字段。
+type Graph struct {
+	Columns []Column `json:"columns"`
+	Edges   []Edge   `json:"edges"`
+
+	columns map[string]int
+	edges   map[string]int
+}
+
+// New 返回一个空的血缘图。
+func New() *Graph {
+	return &Graph{columns: map[string]int{}, edges: map[string]int{}}
+}
+
+// Build 根据 DWS 表规格和流程中的导出、Sqoop 步骤构建血缘图。
+func Build(tables []*parser.DwsTable, processes []model.DemoProcess) *Graph {
+	g := New()
+	for _, t := range tables {
+		g.AddTable(t)
+	}
+	for i := range processes {
+		g.AddProcess(&processes[i])
+	}
+	return g
+}
+
+// tableKey 将表名归一化为不带 schema 的大写形式。
+func tableKey(name string) string {
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+func (g *Graph) addColumn(table, name string, layer Layer) Column {
+	c := Column{Table: tableKey(table), Name: strings.ToUpper(name), Layer: layer}
+	if _, ok := g.columns[c.String()]; !ok {
+		g.columns[c.String()] = len(g.Columns)
+		g.Columns = append(g.Columns, c)
+	}
+	return c
+}
+
+// addEdge 添加一条边；同一对字段的边只保留一条，合并其步骤。
+func (g *Graph) addEdge(e Edge) {
+	key := edgeKey(e)
+	if i, ok := g.edges[key]; ok {
+		g.Edges[i].Steps = mergeSteps(g.Edges[i].Steps, e.Steps)
+		return
+	}
+	e.Steps = mergeSteps(nil, e.Steps)
+	g.edges[key] = len(g.Edges)
+	g.Edges = append(g.Edges, e)
+}
+
+func edgeKey(e Edge) string {
+	return e.From.String() + "->" + e.To.String() + "@" + e.Process
+}
+
+func mergeSteps(a, b []int) []int {
+	seen := make(map[int]struct{}, len(a))
+	for _, id := range a {
+		seen[id] = struct{}{}
+	}
+	for _, id := range b {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			a = append(a, id)
+		}
+	}
+	sort.Ints(a)
+	return a
+}
+
+// hasTable 返回图中是否已有该表的字段。
+func (g *Graph) hasTable(table string) bool {
+	key := tableKey(table)
+	for _, c := range g.Columns {
+		if c.Table == key {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTable 添加 DWS 表规格中的血缘：每个字段由其来源表中字段逻辑引用的字段得到。
+// 没有来源表或字段逻辑中没有引用字段（例如常量）的字段只作为节点加入。
+func (g *Graph) AddTable(t *parser.DwsTable) {
+	for _, f := range t.Fields {
+		to := g.addColumn(t.Name, f.Name, DwsLayer)
+		if f.SourceTable == "" {
+			continue
+		}
+		logic := strings.TrimSpace(f.Logic)
+		if strings.EqualFold(logic, f.Name) {
+			logic = ""
+		}
+		for _, name := range ReferencedColumns(f.Logic) {
+			from := g.addColumn(f.SourceTable, name, SourceLayer)
+			g.addEdge(Edge{From: from, To: to, Logic: logic})
+		}
+	}
+}
+
+// AddProcess 添加流程中的血缘：导出到 HDFS 的每一列经 Sqoop 载入中间表 MID_T_APP_X 后
+// 替换进 APP 表 T_APP_X，APP 字段名取 Sqoop 的 --columns，没有时取导出列的别名。
+// 写入 DWS 表的 Hive 步骤记录到进入该表字段的边上；没有规格的 DWS 表按该步骤的 SQL 推导字段血缘。
+func (g *Graph) AddProcess(p *model.DemoProcess) {
+	for _, load := range []model.LoadType{model.InitializationLoad, model.IncrementalLoad} {
+		var exports []hdfsExport
+		var sqoops []sqoopExport
+		for _, s := range p.Steps {
+			if s.Load != load {
+				continue
+			}
+			if e, ok := parseHdfsExport(s); ok {
+				exports = append(exports, e)
+			}
+			if e, ok := parseSqoopExport(s); ok {
+				sqoops = append(sqoops, e)
+			}
+			if m := reInsertTable.FindStringSubmatch(model.StripComments(s.Content)); m != nil {
+				if !g.hasTable(m[1]) {
+					if parsed, err := parser.ParseHiveSQL(s.Content); err == nil {
+						t, _ := parser.DwsTableFromSQL(parsed.Initialization(), nil)
+						g.AddTable(t)
+					}
+				}
+				g.addWriter(p.Name, m[1], s.ID)
+			}
+		}
+
+		for _, sq := range sqoops {
+			for _, e := range exports {
+				if strings.TrimRight(e.dir, "/") != strings.TrimRight(sq.Dir, "/") {
+					continue
+				}
+				g.addExport(p.Name, e, sq)
+			}
+		}
+	}
+}
+
+// addWriter 记录写入 DWS 表 table 的步骤：规格中进入该表字段的边归属到流程 process，
+// 已归属的边按流程合并步骤，归属其他流程时复制一份。
+func (g *Graph) addWriter(process, table string, stepID int) {
+	key := tableKey(table)
+	for i, e := range g.Edges {
+		if e.To.Table != key {
+			continue
+		}
+		if e.Process == "" {
+			delete(g.edges, edgeKey(e))
+			g.Edges[i].Process, g.Edges[i].Steps = process, []int{stepID}
+			g.edges[edgeKey(g.Edges[i])] = i
+		} else {
+			g.addEdge(Edge{From: e.From, To: e.To, Logic: e.Logic, Process: process, Steps: []int{stepID}})
+		}
+	}
+}
+
+func (g *Graph) addExport(process string, e hdfsExport, sq sqoopExport) {
+	app := strings.TrimPrefix(tableKey(sq.Table), generator.MidTablePrefix)
+	known := g.hasTable(e.table)
+	for i, col := range e.columns {
+		name := col.name()
+		if i < len(sq.Columns) {
+			name = sq.Columns[i]
+		}
+		if name == "" {
+			continue
+		}
+		to := g.addColumn(app, name, AppLayer)
+
+		logic := col.expression
+		refs := ReferencedColumns(col.expression)
+		if len(refs) == 1 && strings.EqualFold(logic, refs[0]) {
+			logic = ""
+		}
+		for _, ref := range refs {
+			ref = strings.ToUpper(ref)
+			if known && ref != PartitionColumn {
+				if _, ok := g.columns[tableKey(e.table)+"."+ref]; !ok {
+					continue
+				}
+			}
+			from := g.addColumn(e.table, ref, DwsLayer)
+			g.addEdge(Edge{From: from, To: to, Logic: logic, Process: process, Steps: []int{e.step.ID, sq.step.ID}})
+		}
+	}
+}
+
+// Upstream 返回进入字段 c 的边。
+func (g *Graph) Upstream(c Column) []Edge {
+	var edges []Edge
+	for _, e := range g.Edges {
+		if e.To.String() == c.String() {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Downstream 返回从字段 c 出发的边。
+func (g *Graph) Downstream(c Column) []Edge {
+	var edges []Edge
+	for _, e := range g.Edges {
+		if e.From.String() == c.String() {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+var (
+	reIdentifier = regexp.MustCompile(`\b([A-Za-z_]\w*)(?:\.([A-Za-z_]\w*))?\b(\s*\()?`)
+	reQuoted     = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+)
+
+// ReferencedColumns 返回表达式引用的字段名（去重，按出现顺序）：跳过字符串常量、函数名和关键字，
+// 带表别名的字段只取字段名。
+func ReferencedColumns(expr string) []string {
+	expr = reQuoted.ReplaceAllString(expr, "''")
+	var names []string
+	seen := map[string]struct{}{}
+	for _, m := range reIdentifier.FindAllStringSubmatch(expr, -1) {
+		if m[3] != "" {
+			continue
+		}
+		name := m[1]
+		if m[2] != "" {
+			name = m[2]
+		}
+		key := strings.ToUpper(name)
+		if model.IsHiveKeyword(key) {
+			continue
+		}
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,93 @@
+package lineage
+
+import (
+	"os"
+	"testing"
+
+	"demo/model"
+	"demo/parser"
+)
+
+const (
+	demoSource = "T_DWD_SA_INTERNAT_TICKING_FLYR_FACT"
+	demoDws    = "T_DWS_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR"
+	demoApp    = "T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR"
+)
+
+// readDemo 读取仓库中的 demo.txt 流程。
+func readDemo(t *testing.T) []model.DemoProcess {
+	t.Helper()
+	content, err := os.ReadFile("../demo.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	process, err := parser.ParseDemoFile(string(content), "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []model.DemoProcess{*process}
+}
+
+// hasEdge 返回图中是否有 from -> to 的边。
+func hasEdge(g *Graph, from, to Column) bool {
+	for _, e := range g.Downstream(from) {
+		if e.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBuildDemo(t *testing.T) {
+	// 没有 tables.txt 规格时，DWS 表的血缘来自流程中的 insert overwrite table 语句。
+	g := Build(nil, readDemo(t))
+
+	tests := []struct {
+		from, to Column
+	}{
+		{Column{demoSource, "TKT_NUM", SourceLayer}, Column{demoDws, "SALE_NUM", DwsLayer}},
+		{Column{demoSource, "TKT_VOYAGE", SourceLayer}, Column{demoDws, "VOYAGE", DwsLayer}},
+		{Column{demoDws, "SALE_NUM", DwsLayer}, Column{demoApp, "SALE_NUM", AppLayer}},
+		{Column{demoDws, PartitionColumn, DwsLayer}, Column{demoApp, "DATA_MONTH", AppLayer}},
+	}
+	for _, tt := range tests {
+		if !hasEdge(g, tt.from, tt.to) {
+			t.Errorf("missing edge %s -> %s", tt.from, tt.to)
+		}
+	}
+}
+
+func TestImpactDemo(t *testing.T) {
+	processes := readDemo(t)
+	impact, err := Build(nil, processes).Impact(demoSource, "tkt_num", processes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(impact.Fields) != 2 {
+		t.Errorf("got %d affected fields, want 2: %+v", len(impact.Fields), impact.Fields)
+	}
+
+	// 创建中间表（5、6）和删除中间目录（11、12）的步骤不读取受影响的数据。
+	const staging = "/tmp/hive/hive/" + demoDws
+	want := []struct {
+		id     int
+		reason string
+	}{
+		{1, "references " + demoSource + ".TKT_NUM"},
+		{2, "references " + demoSource + ".TKT_NUM"},
+		{3, "reads affected table " + demoDws},
+		{4, "reads affected table " + demoDws},
+		{7, "reads " + staging + " written from affected data"},
+		{8, "reads " + staging + " written from affected data"},
+		{9, "writes affected table " + demoApp},
+		{10, "writes affected table " + demoApp},
+	}
+	if len(impact.Steps) != len(want) {
+		t.Fatalf("got %d affected steps, want %d: %+v", len(impact.Steps), len(want), impact.Steps)
+	}
+	for i, w := range want {
+		if s := impact.Steps[i]; s.ID != w.id || s.Reason != w.reason {
+			t.Errorf("step %d: %s, want step %d: %s", s.ID, s.Reason, w.id, w.reason)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package lineage
+
+import (
+	"regexp"
+	"strings"
+
+	"demo/model"
+)
+
+var (
+	reInsertTable = regexp.MustCompile(`(?i)\binsert\s+overwrite\s+table\s+([\w.]+)`)
+	reColumnAlias = regexp.MustCompile(`(?is)^(.*?)\s+(?:AS\s+)?(\w+)$`)
+)
+
+// exportColumn 是导出到 HDFS 的 SELECT 中的一列。
+type exportColumn struct {
+	expression string
+	alias      string
+}
+
+// name 返回导出列的列名：有别名时为别名，否则为表达式引用的字段名。
+func (c exportColumn) name() string {
+	if c.alias != "" {
+		return c.alias
+	}
+	if refs := ReferencedColumns(c.expression); len(refs) == 1 {
+		return refs[0]
+	}
+	return ""
+}
+
+// hdfsExport 是把 DWS 表导出到 HDFS 目录的 Hive 步骤。
+type hdfsExport struct {
+	step    model.Step
+	dir     string
+	table   string
+	columns []exportColumn
+}
+
+// sqoopExport 是把 HDFS 目录载入达梦中间表的 Sqoop 步骤。
+type sqoopExport struct {
+	step model.Step
+	model.SqoopExport
+}
+
+func parseHdfsExport(s model.Step) (hdfsExport, bool) {
+	parsed, ok := model.ParseHdfsExport(s.Content)
+	if !ok {
+		return hdfsExport{}, false
+	}
+	e := hdfsExport{step: s, dir: parsed.Dir}
+	if parsed.Table != "" {
+		e.table = tableKey(parsed.Table)
+	}
+	for _, item := range parsed.Columns {
+		e.columns = append(e.columns, parseExportColumn(item))
+	}
+	return e, true
+}
+
+// parseExportColumn 拆出 SELECT 项的表达式和别名，例如 "dt AS DATA_MONTH"；
+// 末尾的单词前是运算符或者是关键字（例如 case ... end）时不视为别名。
+func parseExportColumn(item string) exportColumn {
+	a := reColumnAlias.FindStringSubmatch(item)
+	if a == nil {
+		return exportColumn{expression: item}
+	}
+	expr := strings.TrimSpace(a[1])
+	last := expr[len(expr)-1]
+	if model.IsHiveKeyword(a[2]) || !(last == ')' || last == '\'' || last == '_' ||
+		'0' <= last && last <= '9' || 'a' <= last && last <= 'z' || 'A' <= last && last <= 'Z') {
+		return exportColumn{expression: item}
+	}
+	return exportColumn{expression: expr, alias: a[2]}
+}
+
+func parseSqoopExport(s model.Step) (sqoopExport, bool) {
+	e, ok := model.ParseSqoopExport(s.Content)
+	return sqoopExport{step: s, SqoopExport: e}, ok
+}
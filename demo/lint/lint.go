@@ -0,0 +1,287 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"demo/generator"
+	"demo/model"
+)
+
+// Severity 是检查结果的严重程度。
+type Severity string
+
+const (
+	// Error 表示脚本在 Hive 中会执行失败或结果错误。
+	Error Severity = "error"
+	// Warning 表示脚本可以执行，但可能有性能或可维护性问题。
+	Warning Severity = "warning"
+)
+
+// Rule 是一条检查规则。
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+// 各检查规则。
+var (
+	MisplacedTerminator = Rule{"HL001", Error, "语句结束符 ; 出现在子句之前，后面的子句会被当作新语句"}
+	EmptyGroupBy        = Rule{"HL002", Error, "GROUP BY 后没有字段"}
+	UngroupedColumn     = Rule{"HL003", Error, "SELECT 中的非聚合表达式不在 GROUP BY 中"}
+	UnknownAlias        = Rule{"HL004", Error, "引用了 FROM/JOIN 中没有定义的表别名"}
+	DuplicateColumn     = Rule{"HL005", Error, "SELECT 的输出列名重复"}
+	UnprunedSource      = Rule{"HL006", Warning, "读取的表没有分区过滤条件，会全表扫描"}
+	UnprunedPartition   = Rule{"HL007", Error, "分区目录中的分区表没有分区过滤条件，会全表扫描"}
+)
+
+// Rules 是 Lint 执行的全部规则，按 ID 排列。
+var Rules = []Rule{MisplacedTerminator, EmptyGroupBy, UngroupedColumn, UnknownAlias, DuplicateColumn, UnprunedSource, UnprunedPartition}
+
+// PartitionColumn 是没有分区目录时假定的源表分区字段。
+const PartitionColumn = "dt"
+
+// Finding 是一条检查结果，Line 为问题所在的行号（从 1 开始）。
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%d: %s %s: %s", f.Line, f.Severity, f.Rule, f.Message)
+}
+
+// HasErrors 返回结果中是否有 Error 级别的问题。
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// linter 保存一次检查的脚本、分区目录、脚本的加载类型和周期参数，以及检查结果。
+type linter struct {
+	script      string
+	catalog     generator.PartitionCatalog
+	load        model.LoadType
+	periodParam string
+	findings    []Finding
+}
+
+func (l *linter) report(rule Rule, offset int, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Rule:     rule.ID,
+		Severity: rule.Severity,
+		Line:     lineOf(l.script, offset),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// original 返回 sp 在原脚本中的文本（含被屏蔽的注释和常量）。
+func (l *linter) original(sp span) string {
+	return l.script[sp.Start : sp.Start+len(sp.Text)]
+}
+
+var reContinuation = regexp.MustCompile(`(?i)^\s*(where|group\s+by|having|order\s+by|sort\s+by|distribute\s+by|cluster\s+by|limit|union|from|(?:left|right|full|inner|cross)?\s*(?:outer\s+)?join)\b`)
+
+// Lint 检查一段 Hive 脚本（可以包含多条以 ; 分隔的语句和 ${mt1} 这样的运行参数），
+// 返回按行号排列的检查结果。不知道哪些表是分区表，读取的每张表都按 dt 检查分区条件（HL006）。
+func Lint(script string) []Finding {
+	return LintWithCatalog(script, nil, "", "")
+}
+
+// LintWithCatalog 与 Lint 相同，但只检查分区目录中的表，并按目录中的分区字段检查，
+// 缺少分区条件时报告 Error 级别的 HL007。load 和 periodParam 为脚本所属步骤的加载类型和周期参数，
+// 用于给出应补上的分区条件，为空时按增量加载和 mt1 处理。catalog 为 nil 时等同于 Lint。
+func LintWithCatalog(script string, catalog generator.PartitionCatalog, load model.LoadType, periodParam string) []Finding {
+	if load == "" {
+		load = model.IncrementalLoad
+	}
+	if periodParam == "" {
+		periodParam = "mt1"
+	}
+	l := &linter{script: script, catalog: catalog, load: load, periodParam: periodParam}
+	masked := maskScript(script)
+
+	start := 0
+	for i := 0; i <= len(masked); i++ {
+		if i < len(masked) && masked[i] != ';' {
+			continue
+		}
+		if i < len(masked) {
+			if m := reContinuation.FindStringSubmatch(masked[i+1:]); m != nil {
+				l.report(MisplacedTerminator, i, "; 之后是 %s 子句，应去掉这个 ; 或移到语句末尾", strings.ToUpper(strings.Join(strings.Fields(m[1]), " ")))
+				continue
+			}
+		}
+		l.lintStatement(masked[start:i], start)
+		start = i + 1
+	}
+
+	sort.SliceStable(l.findings, func(i, j int) bool { return l.findings[i].Line < l.findings[j].Line })
+	return l.findings
+}
+
+func (l *linter) lintStatement(masked string, base int) {
+	st := parseSelect(masked, base)
+	if st == nil {
+		return
+	}
+	l.checkGroupBy(st)
+	l.checkAliases(st)
+	l.checkDuplicates(st)
+	l.checkPartitions(st)
+}
+
+// checkGroupBy 检查 GROUP BY 不为空，且 SELECT 中的非聚合表达式都在 GROUP BY 中。
+// 没有 GROUP BY 但 SELECT 中有聚合函数时，非聚合表达式同样报告。
+func (l *linter) checkGroupBy(st *selectStatement) {
+	if st.GroupBy != nil && len(st.GroupByItems) == 0 {
+		l.report(EmptyGroupBy, st.GroupBy.Start, "GROUP BY 后没有字段")
+		return
+	}
+
+	aggregated := st.GroupBy != nil
+	for _, c := range st.Columns {
+		if reAggregate.MatchString(c.Text) {
+			aggregated = true
+		}
+	}
+	if !aggregated {
+		return
+	}
+
+	grouped := map[string]struct{}{}
+	for _, g := range st.GroupByItems {
+		grouped[normalize(g.Text)] = struct{}{}
+	}
+	for _, c := range st.Columns {
+		expr := expressionOf(c.Text)
+		if reAggregate.MatchString(expr) {
+			continue
+		}
+		if _, ok := grouped[normalize(expr)]; ok {
+			continue
+		}
+		var missing []string
+		for _, ref := range columnRefs(expr) {
+			if _, ok := grouped[ref]; !ok {
+				missing = append(missing, ref)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		text := expressionOf(l.original(c))
+		if st.GroupBy == nil {
+			l.report(UngroupedColumn, c.Start, "%s 与聚合函数一起出现，但语句没有 GROUP BY", text)
+		} else {
+			l.report(UngroupedColumn, c.Start, "%s 不在 GROUP BY 中（缺少 %s）", text, strings.Join(missing, ", "))
+		}
+	}
+}
+
+// checkAliases 检查 alias.col 形式的引用都使用了 FROM/JOIN 中定义的别名或表名。
+func (l *linter) checkAliases(st *selectStatement) {
+	known := map[string]struct{}{}
+	for _, t := range st.Tables {
+		if t.Alias != "" {
+			known[strings.ToLower(t.Alias)] = struct{}{}
+		}
+		name := t.Name
+		if dot := strings.LastIndex(name, "."); dot >= 0 {
+			name = name[dot+1:]
+		}
+		known[strings.ToLower(name)] = struct{}{}
+	}
+
+	// 表名本身（dwd.T_X）不是字段引用，先去掉。
+	body := []byte(st.Body.Text)
+	for _, t := range st.Tables {
+		offset := t.Start - st.Body.Start
+		for i := offset; i >= 0 && i < offset+len(t.Name); i++ {
+			body[i] = ' '
+		}
+	}
+
+	reported := map[string]struct{}{}
+	for _, m := range reColumnRef.FindAllStringSubmatchIndex(string(body), -1) {
+		alias := strings.ToLower(string(body[m[2]:m[3]]))
+		if _, ok := known[alias]; ok {
+			continue
+		}
+		if _, ok := reported[alias]; ok {
+			continue
+		}
+		reported[alias] = struct{}{}
+		ref := l.original(span{Start: st.Body.Start + m[0], Text: string(body[m[0]:m[1]])})
+		l.report(UnknownAlias, st.Body.Start+m[0], "别名 %s 没有在 FROM/JOIN 中定义（%s）", string(body[m[2]:m[3]]), ref)
+	}
+}
+
+// checkDuplicates 检查 SELECT 的输出列名不重复（不区分大小写）。
+func (l *linter) checkDuplicates(st *selectStatement) {
+	seen := map[string]struct{}{}
+	for _, c := range st.Columns {
+		name := outputName(c.Text)
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if _, ok := seen[key]; ok {
+			l.report(DuplicateColumn, c.Start, "输出列 %s 重复", name)
+			continue
+		}
+		seen[key] = struct{}{}
+	}
+}
+
+// checkPartitions 检查每个读取的表在 WHERE 或自身的 JOIN 条件中有分区字段的过滤条件。
+func (l *linter) checkPartitions(st *selectStatement) {
+	for _, t := range st.Tables {
+		if l.catalog == nil {
+			if !hasPartitionFilter(t, st, PartitionColumn) {
+				l.report(UnprunedSource, t.Start, "%s 没有 %s 分区过滤条件", t.Name, PartitionColumn)
+			}
+			continue
+		}
+		p, ok := l.catalog.Lookup(t.Name)
+		if !ok || hasPartitionFilter(t, st, p.Column) {
+			continue
+		}
+		l.report(UnprunedPartition, t.Start, "%s 是按 %s 分区的 %s 表，缺少分区条件，例如 %s",
+			t.Name, p.Column, p.Kind, p.Predicate(t.Alias, l.load, l.periodParam))
+	}
+}
+
+// hasPartitionFilter 返回表 t 是否有分区字段 column 的过滤条件：WHERE 或 t 的 JOIN 条件中
+// 出现 alias.column，只读取一张表时也接受不带限定的 column。
+func hasPartitionFilter(t tableRef, st *selectStatement, column string) bool {
+	var qualifiers []string
+	if t.Alias != "" {
+		qualifiers = append(qualifiers, t.Alias)
+	}
+	name := t.Name
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	qualifiers = append(qualifiers, name)
+
+	conditions := st.Where + "\n" + t.On
+	for _, q := range qualifiers {
+		if regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(q) + `\.` + regexp.QuoteMeta(column) + `\b`).MatchString(conditions) {
+			return true
+		}
+	}
+	if len(st.Tables) == 1 {
+		return regexp.MustCompile(`(?i)(^|[^.\w])` + regexp.QuoteMeta(column) + `\b`).MatchString(conditions)
+	}
+	return false
+}
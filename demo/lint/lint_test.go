@@ -0,0 +1,146 @@
+package lint
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"demo/generator"
+	"demo/model"
+	"demo/parser"
+)
+
+// rulesOf 返回检查结果中的规则 ID 及其行号。
+func rulesOf(findings []Finding) map[string][]int {
+	rules := map[string][]int{}
+	for _, f := range findings {
+		rules[f.Rule] = append(rules[f.Rule], f.Line)
+	}
+	return rules
+}
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+		clean  bool
+	}{
+		{
+			name:   "clean",
+			script: "select s.a, count(1) as n from dwd.t s where s.dt='${mt1}' group by s.a",
+			clean:  true,
+		},
+		{
+			name:   "HL001 terminator before group by",
+			script: "select s.a, count(1) as n from dwd.t s where s.dt='${mt1}';\ngroup by s.a",
+			want:   MisplacedTerminator.ID,
+		},
+		{
+			name:   "HL002 empty group by",
+			script: "select count(1) as n from dwd.t s where s.dt='${mt1}' group by",
+			want:   EmptyGroupBy.ID,
+		},
+		{
+			name:   "HL003 ungrouped column",
+			script: "select s.a, s.b, count(1) as n from dwd.t s where s.dt='${mt1}' group by s.a",
+			want:   UngroupedColumn.ID,
+		},
+		{
+			name:   "HL003 aggregate without group by",
+			script: "select s.a, count(1) as n from dwd.t s where s.dt='${mt1}'",
+			want:   UngroupedColumn.ID,
+		},
+		{
+			name:   "HL004 unknown alias",
+			script: "select x.a from dwd.t s where s.dt='${mt1}'",
+			want:   UnknownAlias.ID,
+		},
+		{
+			name:   "HL005 duplicate column",
+			script: "select s.a, s.b as A from dwd.t s where s.dt='${mt1}'",
+			want:   DuplicateColumn.ID,
+		},
+		{
+			name:   "HL006 unpruned source",
+			script: "select s.a from dwd.t s left join dim.d d on s.k = d.k where s.dt='${mt1}'",
+			want:   UnprunedSource.ID,
+		},
+		{
+			name:   "comments and strings are ignored",
+			script: "select s.a, 'x.y; group by' as b -- ; group by\nfrom dwd.t s where s.dt='${mt1}'",
+			clean:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := Lint(tt.script)
+			if tt.clean {
+				if len(findings) != 0 {
+					t.Errorf("Lint() = %v, want no findings", findings)
+				}
+				return
+			}
+			if _, ok := rulesOf(findings)[tt.want]; !ok {
+				t.Errorf("Lint() = %v, want %s", findings, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintWithCatalog(t *testing.T) {
+	catalog, err := generator.ParsePartitionCatalog("dwd.T_FACT: dt periodic\ndim.T_DIM: dt snapshot\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := "select s.a from dwd.T_FACT s left join dim.T_DIM d on s.k = d.k left join dim.T_OTHER o on s.k = o.k"
+
+	findings := LintWithCatalog(script, catalog, model.InitializationLoad, "mt1")
+	if len(findings) != 2 {
+		t.Fatalf("LintWithCatalog() = %v, want 2 findings", findings)
+	}
+	for _, f := range findings {
+		if f.Rule != UnprunedPartition.ID || f.Severity != Error {
+			t.Errorf("finding %v, want error %s", f, UnprunedPartition.ID)
+		}
+	}
+	// 初始化加载读取周期分区表截至当前周期的全部分区。
+	if want := "s.dt<='${mt1}'"; !strings.Contains(findings[0].Message, want) {
+		t.Errorf("message %q does not suggest %s", findings[0].Message, want)
+	}
+	if want := "d.dt=max_pt('dim','T_DIM')"; !strings.Contains(findings[1].Message, want) {
+		t.Errorf("message %q does not suggest %s", findings[1].Message, want)
+	}
+
+	filtered := "select s.a from dwd.T_FACT s left join dim.T_DIM d on s.k = d.k and d.dt=max_pt('dim','T_DIM') where s.dt='${mt1}'"
+	if findings := LintWithCatalog(filtered, catalog, model.IncrementalLoad, "mt1"); len(findings) != 0 {
+		t.Errorf("LintWithCatalog() = %v, want no findings", findings)
+	}
+}
+
+// demo.txt 的第 2 步在 group by 之前多了一个 ;。
+func TestLintDemoStep2(t *testing.T) {
+	content, err := os.ReadFile("../demo.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	process, err := parser.ParseDemoFile(string(content), "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range process.Steps {
+		if s.ID != 2 {
+			continue
+		}
+		findings := Lint(s.Content)
+		if !HasErrors(findings) {
+			t.Fatalf("Lint(step 2) = %v, want errors", findings)
+		}
+		lines := rulesOf(findings)[MisplacedTerminator.ID]
+		if len(lines) != 1 || lines[0] != 30 {
+			t.Errorf("HL001 at lines %v, want [30]", lines)
+		}
+		return
+	}
+	t.Fatal("demo.txt has no step 2")
+}
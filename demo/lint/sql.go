@@ -0,0 +1,215 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+
+	"demo/model"
+)
+
+// maskScript 把 -- 注释和字符串常量的内容替换为空格（保留引号），长度和换行不变，
+// 之后按关键字查找时不会误中注释或常量，偏移量仍对应原脚本。
+func maskScript(script string) string {
+	b := []byte(script)
+	var quote byte
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c != '\n' {
+				b[i] = ' '
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '-' && i+1 < len(b) && b[i+1] == '-':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		}
+	}
+	return string(b)
+}
+
+// maskNested 把括号内的内容替换为空格（保留括号），用于只匹配顶层的子句和逗号。
+func maskNested(s string) string {
+	b := []byte(s)
+	depth := 0
+	for i, c := range b {
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0 && c != '\n':
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// span 是脚本中的一段文本，Start 为它在脚本中的字节偏移。
+type span struct {
+	Start int
+	Text  string
+}
+
+// splitTopLevel 按括号和引号之外的逗号拆分 text（base 为它在脚本中的偏移），去掉首尾空白和空项。
+func splitTopLevel(text string, base int) []span {
+	var items []span
+	for _, item := range model.SplitTopLevelItems(text) {
+		items = append(items, span{Start: base + item.Offset, Text: item.Text})
+	}
+	return items
+}
+
+// lineOf 返回偏移量 offset 所在的行号（从 1 开始）。
+func lineOf(script string, offset int) int {
+	return strings.Count(script[:offset], "\n") + 1
+}
+
+var (
+	reSelect      = regexp.MustCompile(`(?i)\bselect\b`)
+	reFrom        = regexp.MustCompile(`(?i)\bfrom\b`)
+	reClauseStart = regexp.MustCompile(`(?i)\b(where|group\s+by|having|order\s+by|sort\s+by|distribute\s+by|cluster\s+by|limit|union)\b`)
+	reTableRef    = regexp.MustCompile(`(?i)\b(from|join)\s+([A-Za-z_][\w.]*)(?:\s+(?:as\s+)?([A-Za-z_]\w*))?`)
+	reAggregate   = regexp.MustCompile(`(?i)\b(sum|count|avg|min|max|collect_set|collect_list|stddev|variance)\s*\(`)
+	reColumnRef   = regexp.MustCompile(`\b([A-Za-z_]\w*)\.([A-Za-z_]\w*)\b`)
+	reIdentifier  = regexp.MustCompile(`\b([A-Za-z_]\w*)\b(\s*\()?`)
+	reColumnAlias = regexp.MustCompile(`(?is)^(.*?[\w)'\]])\s+(?:as\s+)?([A-Za-z_]\w*)$`)
+)
+
+// tableRef 是 FROM 或 JOIN 读取的表，On 为 JOIN 的关联条件（FROM 的表为空）。
+type tableRef struct {
+	Start int
+	Name  string
+	Alias string
+	On    string
+}
+
+// selectStatement 是一条语句中顶层 SELECT 的各部分，均取自去掉注释和常量后的文本。
+type selectStatement struct {
+	Columns []span
+	Tables  []tableRef
+	Where   string
+	GroupBy *span
+	// GroupByItems 为 GROUP BY 的各项，GroupBy 为 nil 时为空。
+	GroupByItems []span
+	// Body 为 SELECT 之后的全部文本，用于查找限定字段。
+	Body span
+}
+
+// parseSelect 解析语句 masked（base 为它在脚本中的偏移）中的顶层 SELECT，没有时返回 nil。
+func parseSelect(masked string, base int) *selectStatement {
+	top := maskNested(masked)
+	sel := reSelect.FindStringIndex(top)
+	if sel == nil {
+		return nil
+	}
+	from := reFrom.FindStringIndex(top[sel[1]:])
+	if from == nil {
+		return nil
+	}
+	fromStart, fromEnd := sel[1]+from[0], sel[1]+from[1]
+	st := &selectStatement{
+		Columns: splitTopLevel(masked[sel[1]:fromStart], base+sel[1]),
+		Body:    span{Start: base + sel[1], Text: masked[sel[1]:]},
+	}
+
+	// FROM 子句到下一个顶层子句为止，其后依次是 WHERE、GROUP BY 等。
+	type clause struct {
+		name       string
+		start, end int
+	}
+	var clauses []clause
+	for _, m := range reClauseStart.FindAllStringSubmatchIndex(top[fromEnd:], -1) {
+		name := strings.ToLower(strings.Join(strings.Fields(top[fromEnd+m[2]:fromEnd+m[3]]), " "))
+		clauses = append(clauses, clause{name: name, start: fromEnd + m[0], end: fromEnd + m[1]})
+	}
+	fromClauseEnd := len(masked)
+	if len(clauses) > 0 {
+		fromClauseEnd = clauses[0].start
+	}
+	for i, c := range clauses {
+		end := len(masked)
+		if i+1 < len(clauses) {
+			end = clauses[i+1].start
+		}
+		switch c.name {
+		case "where":
+			st.Where = masked[c.end:end]
+		case "group by":
+			st.GroupBy = &span{Start: base + c.start, Text: masked[c.start:end]}
+			st.GroupByItems = splitTopLevel(masked[c.end:end], base+c.end)
+		}
+	}
+
+	refs := reTableRef.FindAllStringSubmatchIndex(top[fromStart:fromClauseEnd], -1)
+	for i, m := range refs {
+		ref := tableRef{Start: base + fromStart + m[4], Name: top[fromStart+m[4] : fromStart+m[5]]}
+		next := fromClauseEnd
+		if i+1 < len(refs) {
+			next = fromStart + refs[i+1][0]
+		}
+		rest := masked[fromStart+m[1] : next]
+		if m[6] >= 0 {
+			alias := top[fromStart+m[6] : fromStart+m[7]]
+			if model.IsHiveKeyword(alias) {
+				rest = masked[fromStart+m[6] : next]
+			} else {
+				ref.Alias = alias
+			}
+		}
+		if on := regexp.MustCompile(`(?i)\bon\b`).FindStringIndex(rest); on != nil {
+			ref.On = rest[on[1]:]
+			// 去掉下一个 JOIN 之前的 left/inner 等连接类型关键字。
+			ref.On = regexp.MustCompile(`(?i)(\s+(left|right|full|inner|outer|cross|semi|anti))+\s*$`).ReplaceAllString(ref.On, "")
+		}
+		st.Tables = append(st.Tables, ref)
+	}
+	return st
+}
+
+// outputName 返回 SELECT 项的输出列名：别名，或者字段引用的字段名；表达式没有别名时返回空串。
+func outputName(item string) string {
+	if m := reColumnAlias.FindStringSubmatch(item); m != nil && !model.IsHiveKeyword(m[2]) {
+		return m[2]
+	}
+	if m := regexp.MustCompile(`^(?:[A-Za-z_]\w*\.)?([A-Za-z_]\w*)$`).FindStringSubmatch(item); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// expressionOf 返回 SELECT 项去掉别名后的表达式。
+func expressionOf(item string) string {
+	if m := reColumnAlias.FindStringSubmatch(item); m != nil && !model.IsHiveKeyword(m[2]) {
+		return strings.TrimSpace(m[1])
+	}
+	return item
+}
+
+// normalize 去掉空白并转为小写，用于比较表达式。
+func normalize(expr string) string {
+	return strings.ToLower(strings.Join(strings.Fields(expr), ""))
+}
+
+// columnRefs 返回表达式引用的字段（带限定时为 alias.col，否则为 col，均为小写），跳过函数名和关键字。
+func columnRefs(expr string) []string {
+	var refs []string
+	qualified := reColumnRef.FindAllStringIndex(expr, -1)
+	for _, m := range qualified {
+		refs = append(refs, strings.ToLower(expr[m[0]:m[1]]))
+	}
+	rest := reColumnRef.ReplaceAllStringFunc(expr, func(s string) string { return strings.Repeat(" ", len(s)) })
+	for _, m := range reIdentifier.FindAllStringSubmatch(rest, -1) {
+		if m[2] != "" || model.IsHiveKeyword(m[1]) {
+			continue
+		}
+		refs = append(refs, strings.ToLower(m[1]))
+	}
+	return refs
+}
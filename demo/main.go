@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
+	"demo/generator"
 	// "demo/model"
 	"demo/parser"
 	// "demo/steps"
@@ -116,8 +118,20 @@ func main() {
 	// fmt.Println("--- (独立实现) 动态生成的 Sqoop 命令 (步骤 8: 数据载入-增量) ---")
 	// fmt.Println(generatedSqoopExportIncr)
 	// fmt.Println("------------------------------------------------------------------")
+	// 子命令（render 等）见 commands.go；不带参数时保持原有的按 tables.txt 生成 SQL 的行为。
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	// 1. 定义配置文件的路径
-	filePath := "C:/Users/LT/Desktop/workflowStr/demo/tables.txt"
+	generateFromTables("C:/Users/LT/Desktop/workflowStr/demo/tables.txt")
+}
+
+// generateFromTables 读取 tables.txt 配置文件，并为其中每个 DWS 表打印初始化和增量 SQL。
+func generateFromTables(filePath string) {
 	fmt.Printf("正在读取配置文件: %s\n", filePath)
 
 	// 2. 读取文件内容
@@ -132,6 +146,21 @@ func main() {
 		log.Fatalf("解析文件内容时出错: %v", err)
 	}
 
+	// 与 tables.txt 同目录的 catalog.txt 为源表分区目录，存在时为生成的 SQL 补上分区条件。
+	var catalog generator.PartitionCatalog
+	if content, err := os.ReadFile(filepath.Join(filepath.Dir(filePath), "catalog.txt")); err == nil {
+		if catalog, err = generator.ParsePartitionCatalog(string(content)); err != nil {
+			log.Fatalf("解析分区目录时出错: %v", err)
+		}
+	}
+	// 同目录的 udfs.txt 为自定义函数目录，存在时生成的 SQL 开头会注册用到的自定义函数。
+	var udfs generator.UDFRegistry
+	if content, err := os.ReadFile(filepath.Join(filepath.Dir(filePath), "udfs.txt")); err == nil {
+		if udfs, err = generator.ParseUDFRegistry(string(content)); err != nil {
+			log.Fatalf("解析自定义函数目录时出错: %v", err)
+		}
+	}
+
 	fmt.Printf("成功解析出 %d 个DWS表的定义。\n", len(dwsTables))
 	fmt.Println("==================================================")
 
@@ -146,11 +175,30 @@ func main() {
 			continue
 		}
 
+		hiveSQLConfig.AddPartitionFilters(catalog, parser.DefaultPeriodParam)
+
 		// 6. 调用生成器，根据配置生成最终的SQL字符串
-		finalSQL := hiveSQLConfig.GenerateSQL()
+		finalSQL := udfs.WithPreamble(hiveSQLConfig.GenerateSQL())
 
 		// 7. 打印结果
 		fmt.Println(finalSQL)
+
+		// 8. 根据备注中的加载策略生成增量SQL
+		incrConfig, err := dwsTable.ToHiveIncrementalSQLConfig()
+		if err != nil {
+			log.Printf("警告: 无法为表 %s 生成增量SQL: %v\n", dwsTable.Name, err)
+		} else {
+			incrConfig.AddPartitionFilters(catalog, parser.DefaultPeriodParam)
+			fmt.Println()
+			fmt.Println(udfs.WithPreamble(incrConfig.Generate()))
+		}
+		// 9. 根据字段列表和字段类型生成导出到 HDFS 的脚本
+		fmt.Println()
+		fmt.Println(dwsTable.ToHiveToHdfsConfig().Generate())
+		if exportConfig, err := dwsTable.ToHiveToHdfsIncrConfig(); err == nil {
+			fmt.Println()
+			fmt.Println(exportConfig.Generate())
+		}
 		fmt.Printf("--- 表 [%s] 的SQL已生成 (%d/%d) ---\n", dwsTable.Name, i+1, len(dwsTables))
 		fmt.Println("==================================================")
 	}
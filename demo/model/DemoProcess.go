@@ -1,8 +1,11 @@
 package model
 
 // DemoProcess 代表一个完整的处理流程，例如整个 demo.txt 的内容。
-// 它包含了该流程下的所有步骤。
+// 它包含了该流程下的所有步骤以及脚本中使用的运行参数。
+// OnFailure 中的步骤只在同一加载类型的某个步骤失败后执行，用于清理中间目录和中间表。
 type DemoProcess struct {
-	Name  string `json:"name"`
-	Steps []Step `json:"steps"`
+	Name      string     `json:"name"`
+	Params    []RunParam `json:"params,omitempty"`
+	Steps     []Step     `json:"steps"`
+	OnFailure []Step     `json:"onFailure,omitempty"`
 }
@@ -1,7 +1,171 @@
 package model
 
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
 // ProcessCollection 代表一组 DemoProcess 对象。
 type ProcessCollection struct {
 	Name      string        `json:"name"`
 	Processes []DemoProcess `json:"processes"`
 }
+
+// 用于从步骤脚本中识别写入和读取的表，例如 "insert overwrite table dws.T_X" 和 "from dwd.T_Y s"。
+var (
+	reTargetTable = regexp.MustCompile(`(?i)\binsert\s+(?:overwrite|into)\s+table\s+([\w.]+)`)
+	reSourceTable = regexp.MustCompile(`(?i)\b(?:from|join)\s+([A-Za-z_][\w]*(?:\.[\w]+)?)`)
+)
+
+// tableKey 将表名归一化为不带 schema 的大写形式，使 DWS.T_X 与 dws.T_X 视为同一张表。
+func tableKey(name string) string {
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	return strings.ToUpper(name)
+}
+
+// StripComments 去掉 SQL 中以 -- 开头的注释，避免把被注释掉的 join、条件或导出目录当作有效内容。
+func StripComments(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if idx := strings.Index(line, "--"); idx >= 0 {
+			lines[i] = line[:idx]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Targets 返回流程中各步骤写入的表（归一化后的表名，已排序）。
+func (p *DemoProcess) Targets() []string {
+	set := make(map[string]struct{})
+	for _, s := range p.Steps {
+		for _, m := range reTargetTable.FindAllStringSubmatch(StripComments(s.Content), -1) {
+			set[tableKey(m[1])] = struct{}{}
+		}
+	}
+	return sortedKeys(set)
+}
+
+// Sources 返回流程中各步骤读取、但不由流程自身写入的表（归一化后的表名，已排序）。
+func (p *DemoProcess) Sources() []string {
+	targets := make(map[string]struct{})
+	for _, t := range p.Targets() {
+		targets[t] = struct{}{}
+	}
+	set := make(map[string]struct{})
+	for _, s := range p.Steps {
+		for _, m := range reSourceTable.FindAllStringSubmatch(StripComments(s.Content), -1) {
+			key := tableKey(m[1])
+			if _, own := targets[key]; !own {
+				set[key] = struct{}{}
+			}
+		}
+	}
+	return sortedKeys(set)
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Dependencies 返回每个流程依赖的上游流程名称：若流程读取的表由另一个流程写入，则依赖该流程。
+func (c *ProcessCollection) Dependencies() map[string][]string {
+	producers := make(map[string][]string)
+	for _, p := range c.Processes {
+		for _, t := range p.Targets() {
+			producers[t] = append(producers[t], p.Name)
+		}
+	}
+
+	deps := make(map[string][]string, len(c.Processes))
+	for _, p := range c.Processes {
+		set := make(map[string]struct{})
+		for _, src := range p.Sources() {
+			for _, producer := range producers[src] {
+				if producer != p.Name {
+					set[producer] = struct{}{}
+				}
+			}
+		}
+		deps[p.Name] = sortedKeys(set)
+	}
+	return deps
+}
+
+// SharedSources 返回被多个流程读取的源表及读取它们的流程，便于识别共享同一 DWD 事实表的流程。
+func (c *ProcessCollection) SharedSources() map[string][]string {
+	readers := make(map[string][]string)
+	for _, p := range c.Processes {
+		for _, src := range p.Sources() {
+			readers[src] = append(readers[src], p.Name)
+		}
+	}
+	for src, names := range readers {
+		if len(names) < 2 {
+			delete(readers, src)
+		}
+	}
+	return readers
+}
+
+// BuildOrder 返回按依赖关系排序的流程：每个流程都排在其所有上游流程之后，
+// 没有依赖关系的流程保持原有顺序。存在循环依赖时返回错误。
+func (c *ProcessCollection) BuildOrder() ([]DemoProcess, error) {
+	byName := make(map[string]DemoProcess, len(c.Processes))
+	for _, p := range c.Processes {
+		if _, exists := byName[p.Name]; exists {
+			return nil, fmt.Errorf("集合 %s 中流程名 %s 重复", c.Name, p.Name)
+		}
+		byName[p.Name] = p
+	}
+
+	deps := c.Dependencies()
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(c.Processes))
+	var order []DemoProcess
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return fmt.Errorf("流程之间存在循环依赖: %s -> %s", strings.Join(path[i:], " -> "), name)
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range deps[name] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, byName[name])
+		return nil
+	}
+
+	for _, p := range c.Processes {
+		if err := visit(p.Name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
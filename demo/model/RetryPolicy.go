@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// RetryPolicy 描述步骤失败后的重试次数、重试间隔以及单次执行的超时时间。
+// Backoff 为第一次重试前的等待时间，之后每次重试翻倍。
+type RetryPolicy struct {
+	Retries int           `json:"retries"`
+	Backoff time.Duration `json:"backoff"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+// DefaultPolicy 返回步骤在未声明策略时使用的默认策略，取决于命令类型：
+// Sqoop 导出通常耗时最长且容易受 YARN 资源影响，其次是 Hive SQL，达梦存储过程一般较快。
+func DefaultPolicy(s Step) RetryPolicy {
+	switch s.ResolvedCommandType() {
+	case HiveSQLCommand:
+		return RetryPolicy{Retries: 2, Backoff: 5 * time.Minute, Timeout: 2 * time.Hour}
+	case DMProcCommand:
+		return RetryPolicy{Retries: 3, Backoff: 2 * time.Minute, Timeout: time.Hour}
+	default:
+		if strings.Contains(strings.ToLower(s.Content), "sqoop") {
+			return RetryPolicy{Retries: 2, Backoff: 10 * time.Minute, Timeout: 4 * time.Hour}
+		}
+		return RetryPolicy{Retries: 1, Backoff: time.Minute, Timeout: 30 * time.Minute}
+	}
+}
+
+// EffectivePolicy 返回步骤声明的策略；未声明时返回 DefaultPolicy。
+func (s Step) EffectivePolicy() RetryPolicy {
+	if s.Policy != nil {
+		return *s.Policy
+	}
+	return DefaultPolicy(s)
+}
@@ -0,0 +1,17 @@
+package model
+
+// Granularity 定义了运行参数的时间粒度。
+type Granularity string
+
+const (
+	MonthGranularity Granularity = "month"
+	DayGranularity   Granularity = "day"
+)
+
+// RunParam 描述流程声明的一个运行参数，例如脚本中的 ${mt1}。
+// 参数值由业务日期加上偏移量得到，偏移量的单位由 Granularity 决定。
+type RunParam struct {
+	Name        string      `json:"name"`
+	Granularity Granularity `json:"granularity"`
+	Offset      int         `json:"offset"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "strings"
+
 // LoadType 定义了加载类型是“初始化”还是“增量”。
 type LoadType string
 
@@ -8,10 +10,60 @@ const (
 	IncrementalLoad    LoadType = "增量"
 )
 
+// Code 返回加载类型的 ASCII 代号（init/incr），用于运行 ID、目录名等不适合出现中文的场合。
+func (l LoadType) Code() string {
+	switch l {
+	case InitializationLoad:
+		return "init"
+	case IncrementalLoad:
+		return "incr"
+	default:
+		return string(l)
+	}
+}
+
+// CommandType 定义了步骤脚本的执行方式，取值与 demo.go 中 Step.CommandType 一致。
+type CommandType string
+
+const (
+	HiveSQLCommand CommandType = "hivesql"
+	ShellCommand   CommandType = "shell"
+	DMProcCommand  CommandType = "dm_proc"
+)
+
 // Step 代表数据处理作业中的单个步骤。
+// DependsOn 列出必须先于本步骤完成的步骤 ID，这些步骤必须属于同一加载类型。
+// Datasource 为执行步骤所用的数据源名称，为空时使用命令类型对应的默认数据源。
+// Policy 为空时使用按命令类型确定的默认重试与超时策略，见 EffectivePolicy。
 type Step struct {
-	ID      int      `json:"id"`
-	Name    string   `json:"name"`
-	Load    LoadType `json:"load"`
-	Content string   `json:"content"`
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Load        LoadType     `json:"load"`
+	CommandType CommandType  `json:"commandType,omitempty"`
+	Datasource  string       `json:"datasource,omitempty"`
+	DependsOn   []int        `json:"dependsOn,omitempty"`
+	Policy      *RetryPolicy `json:"policy,omitempty"`
+	Content     string       `json:"content"`
+}
+
+// ResolvedCommandType 返回步骤声明的命令类型；未声明时根据脚本内容推断。
+func (s Step) ResolvedCommandType() CommandType {
+	if s.CommandType != "" {
+		return s.CommandType
+	}
+	return InferCommandType(s.Content)
+}
+
+// InferCommandType 根据脚本内容推断命令类型：
+// SET/INSERT 开头的是 Hive SQL，p_xxx(...) 形式的是达梦存储过程调用，其余按 shell 处理。
+func InferCommandType(content string) CommandType {
+	lower := strings.ToLower(strings.TrimSpace(content))
+	switch {
+	case strings.HasPrefix(lower, "set ") || strings.HasPrefix(lower, "insert ") || strings.HasPrefix(lower, "select "):
+		return HiveSQLCommand
+	case strings.HasPrefix(lower, "p_") && strings.Contains(lower, "("):
+		return DMProcCommand
+	default:
+		return ShellCommand
+	}
 }
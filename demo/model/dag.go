@@ -0,0 +1,195 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError 汇总了流程校验中发现的所有问题。
+type ValidationError struct {
+	Process  string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("流程 %s 校验失败:\n  %s", e.Process, strings.Join(e.Problems, "\n  "))
+}
+
+// HasExplicitDependencies 判断流程中是否有步骤显式声明了依赖。
+func (p *DemoProcess) HasExplicitDependencies() bool {
+	for _, s := range p.Steps {
+		if len(s.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Dependencies 返回每个步骤 ID 依赖的步骤 ID。
+// 如果流程中没有任何步骤声明依赖，则沿用旧的线性约定：同一加载类型内按 ID 顺序依次依赖前一个步骤。
+func (p *DemoProcess) Dependencies() map[int][]int {
+	deps := make(map[int][]int, len(p.Steps))
+	if p.HasExplicitDependencies() {
+		for _, s := range p.Steps {
+			deps[s.ID] = append([]int(nil), s.DependsOn...)
+		}
+		return deps
+	}
+
+	steps := append([]Step(nil), p.Steps...)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].ID < steps[j].ID })
+	previous := make(map[LoadType]int)
+	for _, s := range steps {
+		if prev, ok := previous[s.Load]; ok {
+			deps[s.ID] = []int{prev}
+		} else {
+			deps[s.ID] = nil
+		}
+		previous[s.Load] = s.ID
+	}
+	return deps
+}
+
+// Validate 检查步骤依赖是否构成合法的有向无环图：
+// 步骤 ID 不能重复，依赖的步骤必须存在且属于同一加载类型（否则该步骤在本加载类型中永远无法执行），
+// 并且依赖关系中不能有环。
+func (p *DemoProcess) Validate() error {
+	var problems []string
+	byID := make(map[int]Step, len(p.Steps))
+	for _, s := range p.Steps {
+		if _, exists := byID[s.ID]; exists {
+			problems = append(problems, fmt.Sprintf("步骤 ID %d 重复", s.ID))
+			continue
+		}
+		byID[s.ID] = s
+	}
+
+	deps := p.Dependencies()
+	for _, s := range p.Steps {
+		for _, dep := range deps[s.ID] {
+			target, ok := byID[dep]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("步骤 %d 依赖的步骤 %d 不存在", s.ID, dep))
+			case dep == s.ID:
+				problems = append(problems, fmt.Sprintf("步骤 %d 依赖自身", s.ID))
+			case target.Load != s.Load:
+				problems = append(problems, fmt.Sprintf("步骤 %d（%s）依赖了%s步骤 %d，在%s流程中不可达", s.ID, s.Load, target.Load, dep, s.Load))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		if cycle := findCycle(p.Steps, deps); len(cycle) > 0 {
+			parts := make([]string, len(cycle))
+			for i, id := range cycle {
+				parts[i] = fmt.Sprint(id)
+			}
+			problems = append(problems, fmt.Sprintf("步骤依赖存在环: %s", strings.Join(parts, " -> ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Process: p.Name, Problems: problems}
+	}
+	return nil
+}
+
+// findCycle 使用深度优先搜索查找依赖环，返回环上的步骤 ID（首尾相同），无环时返回 nil。
+func findCycle(steps []Step, deps map[int][]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int]int, len(steps))
+	var stack []int
+
+	var visit func(id int) []int
+	visit = func(id int) []int {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dep := range deps[id] {
+			switch state[dep] {
+			case visiting:
+				for i, sid := range stack {
+					if sid == dep {
+						return append(append([]int(nil), stack[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, s := range steps {
+		if state[s.ID] == unvisited {
+			if cycle := visit(s.ID); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Levels 返回加载类型为 load 的步骤的分层拓扑序（load 为空时包含所有步骤）：
+// 每一层中的步骤只依赖之前各层的步骤，因此同一层内的步骤可以并行执行。层内按步骤 ID 排序。
+func (p *DemoProcess) Levels(load LoadType) ([][]Step, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	deps := p.Dependencies()
+	remaining := make(map[int]int)
+	dependents := make(map[int][]int)
+	byID := make(map[int]Step)
+	for _, s := range p.Steps {
+		if load != "" && s.Load != load {
+			continue
+		}
+		byID[s.ID] = s
+		remaining[s.ID] = len(deps[s.ID])
+		for _, dep := range deps[s.ID] {
+			dependents[dep] = append(dependents[dep], s.ID)
+		}
+	}
+
+	var levels [][]Step
+	for len(remaining) > 0 {
+		var level []Step
+		for id, n := range remaining {
+			if n == 0 {
+				level = append(level, byID[id])
+			}
+		}
+		sort.Slice(level, func(i, j int) bool { return level[i].ID < level[j].ID })
+		for _, s := range level {
+			delete(remaining, s.ID)
+			for _, d := range dependents[s.ID] {
+				remaining[d]--
+			}
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// TopologicalOrder 返回加载类型为 load 的步骤的拓扑序，即依次展开 Levels 的结果。
+func (p *DemoProcess) TopologicalOrder(load LoadType) ([]Step, error) {
+	levels, err := p.Levels(load)
+	if err != nil {
+		return nil, err
+	}
+	var order []Step
+	for _, level := range levels {
+		order = append(order, level...)
+	}
+	return order, nil
+}
@@ -0,0 +1,168 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ExportDirectoryPattern 匹配 Hive 导出步骤的 INSERT OVERWRITE DIRECTORY '<dir>'，第一个分组为目录。
+var ExportDirectoryPattern = regexp.MustCompile(`(?i)insert\s+overwrite\s+directory\s+'([^']+)'`)
+
+// 用于从步骤脚本中识别 HDFS 中间目录和达梦中间表：Sqoop 的 --export-dir <dir> 与 --table MID_T_APP_X。
+var (
+	reSqoopExportDir = regexp.MustCompile(`--export-dir\s+(\S+)`)
+	reSqoopMidTable  = regexp.MustCompile(`--table\s+(MID_\w+)`)
+)
+
+// stepsOf 返回加载类型为 load 的步骤，load 为空时返回全部步骤。
+func (p *DemoProcess) stepsOf(load LoadType) []Step {
+	var steps []Step
+	for _, s := range p.Steps {
+		if load == "" || s.Load == load {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// StagingDirs 返回加载类型为 load 的步骤写入或读取的 HDFS 中间目录（去重，按出现顺序）。
+func (p *DemoProcess) StagingDirs(load LoadType) []string {
+	var dirs []string
+	seen := make(map[string]struct{})
+	for _, s := range p.stepsOf(load) {
+		for _, re := range []*regexp.Regexp{ExportDirectoryPattern, reSqoopExportDir} {
+			for _, m := range re.FindAllStringSubmatch(s.Content, -1) {
+				if _, ok := seen[m[1]]; !ok {
+					seen[m[1]] = struct{}{}
+					dirs = append(dirs, m[1])
+				}
+			}
+		}
+	}
+	return dirs
+}
+
+// MidTables 返回加载类型为 load 的步骤通过 Sqoop 写入的达梦中间表（去重，按出现顺序）。
+func (p *DemoProcess) MidTables(load LoadType) []string {
+	var tables []string
+	seen := make(map[string]struct{})
+	for _, s := range p.stepsOf(load) {
+		for _, m := range reSqoopMidTable.FindAllStringSubmatch(s.Content, -1) {
+			if _, ok := seen[m[1]]; !ok {
+				seen[m[1]] = struct{}{}
+				tables = append(tables, m[1])
+			}
+		}
+	}
+	return tables
+}
+
+// FailureHandlers 返回加载类型为 load 的失败处理步骤，load 为空时返回全部。
+func (p *DemoProcess) FailureHandlers(load LoadType) []Step {
+	var steps []Step
+	for _, s := range p.OnFailure {
+		if load == "" || s.Load == load {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// DataObjectKind 是步骤读写的数据对象类型。
+type DataObjectKind string
+
+const (
+	SourceTableObject DataObjectKind = "源表"
+	DwsTableObject    DataObjectKind = "DWS表"
+	StagingDirObject  DataObjectKind = "HDFS中间目录"
+	MidTableObject    DataObjectKind = "中间表"
+	AppTableObject    DataObjectKind = "APP表"
+)
+
+// DataAccess 是步骤对数据对象的操作。
+type DataAccess string
+
+const (
+	ReadAccess   DataAccess = "读取"
+	WriteAccess  DataAccess = "写入"
+	DeleteAccess DataAccess = "删除"
+)
+
+// DataObject 是步骤读写的一个数据对象，表名为归一化后的形式（见 tableKey）。
+type DataObject struct {
+	Kind   DataObjectKind
+	Name   string
+	Access DataAccess
+}
+
+var (
+	reHdfsRemove    = regexp.MustCompile(`\b(?:hdfs\s+dfs|hadoop\s+d?fs)\s+-rm\s+(?:-\w+\s+)*([^\s;&|]+)`)
+	reCreateMidApp  = regexp.MustCompile(`(?i)\bp_create_mid_app\s*\(\s*'([^']+)'`)
+	reReplaceTarget = regexp.MustCompile(`(?i)\bp_replace_tgttable\s*\(\s*'([^']+)'`)
+)
+
+// tableKind 按表名前缀判断表的类型：T_DWS_、MID_、T_APP_ 以外的表都视为源表。
+func tableKind(name string) DataObjectKind {
+	switch {
+	case strings.HasPrefix(name, "T_DWS_"):
+		return DwsTableObject
+	case strings.HasPrefix(name, "MID_"):
+		return MidTableObject
+	case strings.HasPrefix(name, "T_APP_"):
+		return AppTableObject
+	default:
+		return SourceTableObject
+	}
+}
+
+// DataObjects 返回步骤读写的数据对象（去重，按出现顺序）：Hive 步骤读写的表和写入的 HDFS 目录，
+// Sqoop 读取的 HDFS 目录和写入的中间表，p_create_mid_app 创建的中间表，p_replace_tgttable
+// 读取的中间表和写入的 APP 表，以及 hdfs dfs -rm（或 hadoop fs/dfs -rm）删除的目录。
+// 只有 Hive 步骤会去掉 -- 注释，shell 步骤中的 --table 等参数保持原样。
+func (s Step) DataObjects() []DataObject {
+	content := s.Content
+	if s.ResolvedCommandType() == HiveSQLCommand {
+		content = StripComments(content)
+	}
+
+	var objects []DataObject
+	seen := make(map[DataObject]struct{})
+	add := func(kind DataObjectKind, name string, access DataAccess) {
+		o := DataObject{Kind: kind, Name: name, Access: access}
+		if _, ok := seen[o]; !ok {
+			seen[o] = struct{}{}
+			objects = append(objects, o)
+		}
+	}
+	addTable := func(name string, access DataAccess) {
+		name = tableKey(name)
+		add(tableKind(name), name, access)
+	}
+
+	for _, m := range reSourceTable.FindAllStringSubmatch(content, -1) {
+		addTable(m[1], ReadAccess)
+	}
+	for _, m := range reTargetTable.FindAllStringSubmatch(content, -1) {
+		addTable(m[1], WriteAccess)
+	}
+	for _, m := range ExportDirectoryPattern.FindAllStringSubmatch(content, -1) {
+		add(StagingDirObject, m[1], WriteAccess)
+	}
+	for _, m := range reSqoopExportDir.FindAllStringSubmatch(content, -1) {
+		add(StagingDirObject, m[1], ReadAccess)
+	}
+	for _, m := range reSqoopMidTable.FindAllStringSubmatch(content, -1) {
+		addTable(m[1], WriteAccess)
+	}
+	for _, m := range reCreateMidApp.FindAllStringSubmatch(content, -1) {
+		addTable("MID_"+m[1], WriteAccess)
+	}
+	for _, m := range reReplaceTarget.FindAllStringSubmatch(content, -1) {
+		addTable("MID_"+m[1], ReadAccess)
+		addTable(m[1], WriteAccess)
+	}
+	for _, m := range reHdfsRemove.FindAllStringSubmatch(content, -1) {
+		add(StagingDirObject, strings.Trim(m[1], `'"`), DeleteAccess)
+	}
+	return objects
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	reSqoopExport     = regexp.MustCompile(`\bsqoop\s+export\b`)
+	reSqoopArg        = regexp.MustCompile(`--([\w-]+)(?:[ \t]+('[^']*'|"[^"]*"|[^\s\\]+))?`)
+	reFieldTerminator = regexp.MustCompile(`(?i)FIELDS\s+TERMINATED\s+BY\s+'([^']*)'`)
+	reExportSelect    = regexp.MustCompile(`(?is)\bSELECT\b(.*?)\bFROM\b\s*([\w.]*)`)
+)
+
+// HdfsExport 是 Hive 导出步骤中 INSERT OVERWRITE DIRECTORY 的各部分。
+type HdfsExport struct {
+	Dir string
+	// Terminator 为 FIELDS TERMINATED BY 指定的分隔符，没有指定时为空。
+	Terminator string
+	// Table 为导出的 SELECT 读取的表（原样，可能带 schema），Columns 为它的各个 SELECT 项。
+	Table   string
+	Columns []string
+}
+
+// ParseHdfsExport 解析 Hive 导出步骤的脚本，不是导出步骤时返回 false。-- 注释被忽略，
+// 只取 DIRECTORY 子句之后的 SELECT。
+func ParseHdfsExport(content string) (HdfsExport, bool) {
+	content = StripComments(content)
+	m := ExportDirectoryPattern.FindStringSubmatchIndex(content)
+	if m == nil {
+		return HdfsExport{}, false
+	}
+	e := HdfsExport{Dir: content[m[2]:m[3]]}
+	if t := reFieldTerminator.FindStringSubmatch(content); t != nil {
+		e.Terminator = t[1]
+	}
+	if sel := reExportSelect.FindStringSubmatch(content[m[1]:]); sel != nil {
+		e.Table = sel[2]
+		e.Columns = SplitTopLevel(sel[1])
+	}
+	return e, true
+}
+
+// SqoopExport 是 shell 步骤中 sqoop export 命令的参数。
+type SqoopExport struct {
+	Table string
+	Dir   string
+	// Terminator 为 --input-fields-terminated-by（或 --fields-terminated-by）指定的分隔符，没有指定时为空。
+	Terminator string
+	Columns    []string
+}
+
+// ParseSqoopExport 解析运行 sqoop export 的 shell 脚本，不是 Sqoop 导出时返回 false。
+func ParseSqoopExport(content string) (SqoopExport, bool) {
+	if !reSqoopExport.MatchString(content) {
+		return SqoopExport{}, false
+	}
+	var e SqoopExport
+	for _, m := range reSqoopArg.FindAllStringSubmatch(content, -1) {
+		value := strings.Trim(m[2], `'"`)
+		switch m[1] {
+		case "table":
+			e.Table = value
+		case "export-dir":
+			e.Dir = value
+		case "input-fields-terminated-by", "fields-terminated-by":
+			e.Terminator = value
+		case "columns":
+			for _, c := range strings.Split(value, ",") {
+				e.Columns = append(e.Columns, strings.TrimSpace(c))
+			}
+		}
+	}
+	return e, true
+}
+
+// ListItem 是逗号分隔列表中的一项，Offset 为它在列表中的字节偏移。
+type ListItem struct {
+	Offset int
+	Text   string
+}
+
+// SplitTopLevelItems 按括号和引号之外的逗号拆分 SELECT 列表等逗号分隔的文本，
+// 去掉每项首尾的空白和空项。多余的右括号被忽略。
+func SplitTopLevelItems(list string) []ListItem {
+	var items []ListItem
+	add := func(start, end int) {
+		raw := list[start:end]
+		if text := strings.TrimSpace(raw); text != "" {
+			items = append(items, ListItem{Offset: start + strings.Index(raw, text), Text: text})
+		}
+	}
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(list); i++ {
+		c := list[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case c == ',' && depth == 0:
+			add(start, i)
+			start = i + 1
+		}
+	}
+	add(start, len(list))
+	return items
+}
+
+// SplitTopLevel 与 SplitTopLevelItems 相同，只返回各项的文本。
+func SplitTopLevel(list string) []string {
+	var texts []string
+	for _, item := range SplitTopLevelItems(list) {
+		texts = append(texts, item.Text)
+	}
+	return texts
+}
+
+// hiveKeywords 是 HiveQL 的关键字和类型名，它们既不是字段，也不能作为表别名。
+var hiveKeywords = map[string]struct{}{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		ON LEFT RIGHT FULL INNER OUTER CROSS JOIN WHERE GROUP HAVING ORDER SORT DISTRIBUTE CLUSTER
+		LIMIT UNION LATERAL SEMI ANTI SELECT FROM AS DISTINCT CASE WHEN THEN ELSE END AND OR NOT NULL
+		IN IS LIKE RLIKE BETWEEN TRUE FALSE INTERVAL CURRENT_TIMESTAMP CURRENT_DATE
+		STRING INT BIGINT DOUBLE DECIMAL DATE TIMESTAMP
+	`) {
+		hiveKeywords[word] = struct{}{}
+	}
+}
+
+// IsHiveKeyword 返回 word 是否为 HiveQL 关键字或类型名（不区分大小写）。
+func IsHiveKeyword(word string) bool {
+	_, ok := hiveKeywords[strings.ToUpper(word)]
+	return ok
+}
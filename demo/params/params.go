@@ -0,0 +1,199 @@
+package params
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"demo/model"
+)
+
+// 各粒度下参数值的格式，与 Hive 脚本中 unix_timestamp('${mt1}','yyyyMM') 的写法保持一致。
+const (
+	monthLayout = "200601"
+	dayLayout   = "20060102"
+)
+
+// RunIDParam 是由执行方（本地 runner 或调度系统）在每次运行时提供的内置参数，流程无需声明。
+const RunIDParam = "run_id"
+
+// rePlaceholder 用于匹配脚本中的 ${name} 占位符。
+var rePlaceholder = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// DefaultParams 返回未显式声明参数的流程所使用的参数：${mt1} 为业务日期的上一个月。
+func DefaultParams() []model.RunParam {
+	return []model.RunParam{
+		{Name: "mt1", Granularity: model.MonthGranularity, Offset: -1},
+	}
+}
+
+// Declared 返回流程声明的参数；如果流程没有声明任何参数，则返回 DefaultParams。
+func Declared(p *model.DemoProcess) []model.RunParam {
+	if len(p.Params) > 0 {
+		return p.Params
+	}
+	return DefaultParams()
+}
+
+// Layout 返回指定粒度的日期格式。
+func Layout(g model.Granularity) (string, error) {
+	switch g {
+	case model.MonthGranularity:
+		return monthLayout, nil
+	case model.DayGranularity:
+		return dayLayout, nil
+	default:
+		return "", fmt.Errorf("未知的参数粒度: %q", g)
+	}
+}
+
+// Shift 将日期按粒度平移 n 个单位。按月平移时先归到月初，避免 1 月 31 日加一个月跳到 3 月。
+func Shift(t time.Time, g model.Granularity, n int) time.Time {
+	if g == model.MonthGranularity {
+		return time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// FormatPeriod 将日期格式化为指定粒度的参数值，例如 202410 或 20241015。
+func FormatPeriod(t time.Time, g model.Granularity) (string, error) {
+	layout, err := Layout(g)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
+
+// ParsePeriod 解析指定粒度的参数值。
+func ParsePeriod(value string, g model.Granularity) (time.Time, error) {
+	layout, err := Layout(g)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无法按 %s 粒度解析 %q: %w", g, value, err)
+	}
+	return t, nil
+}
+
+// Resolve 根据业务日期计算每个声明参数的取值。
+func Resolve(decls []model.RunParam, bizDate time.Time) (map[string]string, error) {
+	values := make(map[string]string, len(decls))
+	for _, d := range decls {
+		if d.Name == "" {
+			return nil, fmt.Errorf("参数名不能为空")
+		}
+		if _, exists := values[d.Name]; exists {
+			return nil, fmt.Errorf("参数 %s 重复声明", d.Name)
+		}
+		value, err := FormatPeriod(Shift(bizDate, d.Granularity, d.Offset), d.Granularity)
+		if err != nil {
+			return nil, fmt.Errorf("参数 %s: %w", d.Name, err)
+		}
+		values[d.Name] = value
+	}
+	return values, nil
+}
+
+// Placeholders 按首次出现的顺序返回脚本中使用的所有占位符名称（去重）。
+func Placeholders(script string) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, m := range rePlaceholder.FindAllStringSubmatch(script, -1) {
+		if _, ok := seen[m[1]]; ok {
+			continue
+		}
+		seen[m[1]] = struct{}{}
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// Check 对比脚本与参数声明，返回脚本中使用但未声明的参数，以及声明了但所有脚本都未使用的参数，均已排序。
+// 内置参数 RunIDParam 视为已声明。
+func Check(scripts []string, decls []model.RunParam) (undeclared, unused []string) {
+	declared := map[string]struct{}{RunIDParam: {}}
+	for _, d := range decls {
+		declared[d.Name] = struct{}{}
+	}
+
+	used := make(map[string]struct{})
+	for _, script := range scripts {
+		for _, name := range Placeholders(script) {
+			if _, ok := used[name]; ok {
+				continue
+			}
+			used[name] = struct{}{}
+			if _, ok := declared[name]; !ok {
+				undeclared = append(undeclared, name)
+			}
+		}
+	}
+
+	for _, d := range decls {
+		if _, ok := used[d.Name]; !ok {
+			unused = append(unused, d.Name)
+		}
+	}
+	sort.Strings(undeclared)
+	sort.Strings(unused)
+	return undeclared, unused
+}
+
+// Render 将脚本中的占位符替换为参数值。若存在没有取值的占位符，则返回错误。
+func Render(script string, values map[string]string) (string, error) {
+	var missing []string
+	rendered := rePlaceholder.ReplaceAllStringFunc(script, func(m string) string {
+		name := rePlaceholder.FindStringSubmatch(m)[1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return m
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("以下参数没有取值: %s", strings.Join(missing, ", "))
+	}
+	return rendered, nil
+}
+
+// 生成器输出的嵌套 Hive 日期函数，分别对应按月和按天的回溯窗口。
+var (
+	reMonthWindow = regexp.MustCompile(`date_format\(\s*add_months\(\s*trunc\(\s*from_unixtime\(\s*unix_timestamp\(\s*'\$\{(\w+)\}'\s*,\s*'yyyyMM'\s*\)\s*,\s*'yyyy-MM'\s*\)\s*,\s*'MM'\s*\)\s*,\s*(-?\d+)\s*\)\s*,\s*'yyyyMM'\s*\)`)
+	reDayWindow   = regexp.MustCompile(`date_format\(\s*date_sub\(\s*from_unixtime\(\s*unix_timestamp\(\s*'\$\{(\w+)\}'\s*,\s*'yyyyMMdd'\s*\)\s*,\s*'yyyy-MM-dd'\s*\)\s*,\s*(-?\d+)\s*\)\s*,\s*'yyyyMMdd'\s*\)`)
+)
+
+// ExpandDateWindows 在 Go 中预先计算脚本里的日期窗口表达式，并将其替换为字符串常量，
+// 例如把 date_format(add_months(trunc(...'${mt1}'...),-1),'yyyyMM') 替换为 '202409'。
+// 无法识别的表达式以及没有取值的参数保持原样。
+func ExpandDateWindows(script string, values map[string]string) string {
+	expand := func(re *regexp.Regexp, g model.Granularity, sign int) func(string) string {
+		return func(m string) string {
+			matches := re.FindStringSubmatch(m)
+			value, ok := values[matches[1]]
+			if !ok {
+				return m
+			}
+			t, err := ParsePeriod(value, g)
+			if err != nil {
+				return m
+			}
+			n, err := strconv.Atoi(matches[2])
+			if err != nil {
+				return m
+			}
+			shifted, _ := FormatPeriod(Shift(t, g, sign*n), g)
+			return "'" + shifted + "'"
+		}
+	}
+
+	script = reMonthWindow.ReplaceAllStringFunc(script, expand(reMonthWindow, model.MonthGranularity, 1))
+	// date_sub 的天数为正时表示向前回溯。
+	script = reDayWindow.ReplaceAllStringFunc(script, expand(reDayWindow, model.DayGranularity, -1))
+	return script
+}
@@ -0,0 +1,369 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"demo/generator"
+	"demo/model"
+)
+
+// Top-level clause keywords recognised by ParseHiveSQL. They are matched against a masked
+// copy of the statement in which parenthesised and quoted text is blanked out, so keywords
+// inside sub-queries, function calls and string literals are ignored.
+var (
+	reInsertClause  = regexp.MustCompile(`(?i)\binsert\s+overwrite\s+table\b`)
+	reSelectClause  = regexp.MustCompile(`(?i)\bselect\b`)
+	reFromClause    = regexp.MustCompile(`(?i)\bfrom\b`)
+	reJoinClause    = regexp.MustCompile(`(?i)\b((?:left|right|full)(?:\s+outer)?\s+join|(?:inner|cross)\s+join|join)\b`)
+	reOnKeyword     = regexp.MustCompile(`(?i)\bon\b`)
+	reWhereClause   = regexp.MustCompile(`(?i)\bwhere\b`)
+	reGroupByClause = regexp.MustCompile(`(?i)\bgroup\s+by\b`)
+	reUnsupported   = regexp.MustCompile(`(?i)\b(having|order\s+by|sort\s+by|distribute\s+by|cluster\s+by|limit|union)\b`)
+	rePartition     = regexp.MustCompile(`(?is)^\s*partition\s*\((.*)\)\s*$`)
+	reColumnAlias   = regexp.MustCompile(`(?is)^(.*\S)\s+as\s+(\w+)$`)
+	reImplicitAlias = regexp.MustCompile(`^(\w+(?:\.\w+)?)\s+(\w+)$`)
+	reCommentedJoin = regexp.MustCompile(`(?is)^((?:left|right|full)(?:\s+outer)?\s+join|(?:inner|cross)\s+join|join)\s+(.+?)\s+on\s+(.+)$`)
+)
+
+// ParsedHiveSQL is the common result of parsing an `insert overwrite table` statement, from
+// which both HiveInitializationSQL and HiveIncrementalSQL are built.
+type ParsedHiveSQL struct {
+	TargetTable     generator.Table
+	PartitionClause string
+	SelectColumns   []generator.ColumnMapping
+	FromTable       generator.Table
+	Joins           []generator.Join
+	WhereClause     string
+	GroupByColumns  []generator.GroupByColumn
+}
+
+// commentLine is a fully commented-out line together with the offset in the active
+// (uncommented) text at which it appeared.
+type commentLine struct {
+	offset int
+	text   string
+}
+
+// ParseHiveSQL parses an `insert overwrite table ... select ... from ... join ... where ...
+// group by ...` statement. Commented-out joins and GROUP BY entries are kept with
+// IsActive=false in their original position; other comments, including commented-out
+// select columns, are dropped since the structured model cannot represent them.
+func ParseHiveSQL(sql string) (*ParsedHiveSQL, error) {
+	active, comments := splitComments(sql)
+	active = strings.TrimRight(strings.TrimSpace(active), ";")
+	mask := maskNested(active)
+
+	if m := reUnsupported.FindStringIndex(mask); m != nil {
+		return nil, fmt.Errorf("unsupported clause %q", active[m[0]:m[1]])
+	}
+
+	insert := reInsertClause.FindStringIndex(mask)
+	if insert == nil || strings.TrimSpace(active[:insert[0]]) != "" {
+		return nil, fmt.Errorf("statement must start with insert overwrite table")
+	}
+	selects := reSelectClause.FindAllStringIndex(mask, -1)
+	if len(selects) != 1 {
+		return nil, fmt.Errorf("expected exactly one top-level select, found %d", len(selects))
+	}
+	from := reFromClause.FindStringIndex(mask)
+	if from == nil || from[0] < selects[0][1] {
+		return nil, fmt.Errorf("missing from clause after select")
+	}
+	where := reWhereClause.FindStringIndex(mask)
+	groupBy := reGroupByClause.FindStringIndex(mask)
+	if where != nil && where[0] < from[1] || groupBy != nil && groupBy[0] < from[1] || where != nil && groupBy != nil && groupBy[0] < where[1] {
+		return nil, fmt.Errorf("clauses must appear in the order select, from, join, where, group by")
+	}
+
+	// end returns where the clause starting at pos ends: at the next where/group by keyword.
+	end := func(pos int) int {
+		for _, next := range [][]int{where, groupBy} {
+			if next != nil && next[0] >= pos {
+				return next[0]
+			}
+		}
+		return len(active)
+	}
+
+	parsed := &ParsedHiveSQL{}
+
+	// insert overwrite table <table> [partition(...)]
+	target := strings.TrimSpace(active[insert[1]:selects[0][0]])
+	if idx := strings.Index(strings.ToLower(target), "partition"); idx >= 0 {
+		m := rePartition.FindStringSubmatch(target[idx:])
+		if m == nil {
+			return nil, fmt.Errorf("cannot parse partition clause %q", target[idx:])
+		}
+		parsed.PartitionClause = strings.TrimSpace(m[1])
+		target = strings.TrimSpace(target[:idx])
+	}
+	table, err := parseTableRef(target)
+	if err != nil {
+		return nil, fmt.Errorf("target table: %w", err)
+	}
+	parsed.TargetTable = table
+
+	// select list
+	for _, item := range model.SplitTopLevelItems(active[selects[0][1]:from[0]]) {
+		parsed.SelectColumns = append(parsed.SelectColumns, parseColumn(item.Text))
+	}
+	if len(parsed.SelectColumns) == 0 {
+		return nil, fmt.Errorf("empty select list")
+	}
+
+	// from <table> followed by joins, up to where/group by
+	fromEnd := end(from[1])
+	joins := reJoinClause.FindAllStringIndex(mask[from[1]:fromEnd], -1)
+	firstJoin := fromEnd
+	if len(joins) > 0 {
+		firstJoin = from[1] + joins[0][0]
+	}
+	if parsed.FromTable, err = parseTableRef(active[from[1]:firstJoin]); err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+
+	type positionedJoin struct {
+		offset int
+		join   generator.Join
+	}
+	var allJoins []positionedJoin
+	for i, j := range joins {
+		start, stop := from[1]+j[0], fromEnd
+		if i+1 < len(joins) {
+			stop = from[1] + joins[i+1][0]
+		}
+		join, err := parseJoin(active[start:from[1]+j[1]], active[from[1]+j[1]:stop], mask[from[1]+j[1]:stop])
+		if err != nil {
+			return nil, err
+		}
+		allJoins = append(allJoins, positionedJoin{start, join})
+	}
+
+	type positionedGroup struct {
+		offset int
+		column generator.GroupByColumn
+	}
+	var groups []positionedGroup
+	if groupBy != nil {
+		for _, item := range model.SplitTopLevelItems(active[groupBy[1]:]) {
+			groups = append(groups, positionedGroup{groupBy[1] + item.Offset, generator.GroupByColumn{Expression: item.Text, IsActive: true}})
+		}
+	}
+
+	for _, c := range comments {
+		text := strings.TrimSpace(c.text)
+		switch {
+		case groupBy != nil && c.offset >= groupBy[1]:
+			expr := strings.TrimSpace(strings.TrimPrefix(text, ","))
+			if expr != "" {
+				groups = append(groups, positionedGroup{c.offset, generator.GroupByColumn{Expression: expr, IsActive: false}})
+			}
+		case c.offset >= from[1] && c.offset <= fromEnd:
+			if m := reCommentedJoin.FindStringSubmatch(text); m != nil {
+				target, err := parseTableRef(m[2])
+				if err != nil {
+					continue
+				}
+				allJoins = append(allJoins, positionedJoin{c.offset, generator.Join{
+					Type: normalizeSpace(strings.ToLower(m[1])), Target: target, Condition: strings.TrimSpace(m[3]), IsActive: false,
+				}})
+			}
+		}
+	}
+
+	sort.SliceStable(allJoins, func(i, j int) bool { return allJoins[i].offset < allJoins[j].offset })
+	for _, j := range allJoins {
+		parsed.Joins = append(parsed.Joins, j.join)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].offset < groups[j].offset })
+	for _, g := range groups {
+		parsed.GroupByColumns = append(parsed.GroupByColumns, g.column)
+	}
+
+	if where != nil {
+		parsed.WhereClause = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(active[where[1]:end(where[1])]), ";"))
+	}
+	return parsed, nil
+}
+
+// ParseHiveInitializationSQL parses a statement without a partition clause into a
+// HiveInitializationSQL.
+func ParseHiveInitializationSQL(sql string) (*generator.HiveInitializationSQL, error) {
+	parsed, err := ParseHiveSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.PartitionClause != "" {
+		return nil, fmt.Errorf("statement writes partition(%s); use ParseHiveIncrementalSQL", parsed.PartitionClause)
+	}
+	return parsed.Initialization(), nil
+}
+
+// Initialization returns the statement as a HiveInitializationSQL, dropping any partition clause.
+func (p *ParsedHiveSQL) Initialization() *generator.HiveInitializationSQL {
+	return &generator.HiveInitializationSQL{
+		TargetTable:    p.TargetTable,
+		SelectColumns:  p.SelectColumns,
+		FromTable:      p.FromTable,
+		Joins:          p.Joins,
+		WhereClause:    p.WhereClause,
+		GroupByColumns: p.GroupByColumns,
+	}
+}
+
+// ParseHiveIncrementalSQL parses a statement, usually one writing a partition such as
+// dt='${mt1}', into a HiveIncrementalSQL.
+func ParseHiveIncrementalSQL(sql string) (*generator.HiveIncrementalSQL, error) {
+	parsed, err := ParseHiveSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+	incrTable := func(t generator.Table) generator.IncrTable {
+		return generator.IncrTable{Schema: t.Schema, Name: t.Name, Alias: t.Alias}
+	}
+	config := &generator.HiveIncrementalSQL{
+		TargetTable:     incrTable(parsed.TargetTable),
+		PartitionClause: parsed.PartitionClause,
+		FromTable:       incrTable(parsed.FromTable),
+		WhereClause:     parsed.WhereClause,
+	}
+	for _, c := range parsed.SelectColumns {
+		config.SelectColumns = append(config.SelectColumns, generator.IncrColumnMapping{Expression: c.Expression, Alias: c.Alias})
+	}
+	for _, j := range parsed.Joins {
+		config.Joins = append(config.Joins, generator.IncrJoin{Type: j.Type, Target: incrTable(j.Target), Condition: j.Condition, IsActive: j.IsActive})
+	}
+	for _, g := range parsed.GroupByColumns {
+		config.GroupByColumns = append(config.GroupByColumns, generator.IncrGroupByColumn{Expression: g.Expression, IsActive: g.IsActive})
+	}
+	return config, nil
+}
+
+// splitComments removes `--` comments from sql. Lines that are entirely commented out are
+// returned separately with their position in the remaining text; trailing comments on
+// code lines are discarded.
+func splitComments(sql string) (string, []commentLine) {
+	var active strings.Builder
+	var comments []commentLine
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			comments = append(comments, commentLine{offset: active.Len(), text: strings.TrimPrefix(trimmed, "--")})
+			active.WriteString("\n")
+			continue
+		}
+		if idx := commentStart(line); idx >= 0 {
+			line = line[:idx]
+		}
+		active.WriteString(line)
+		active.WriteString("\n")
+	}
+	return active.String(), comments
+}
+
+// commentStart returns the index of a `--` comment outside string literals, or -1.
+func commentStart(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '-' && i+1 < len(line) && line[i+1] == '-':
+			return i
+		}
+	}
+	return -1
+}
+
+// maskNested returns a copy of s of the same length in which text inside parentheses and
+// string literals is replaced by spaces.
+func maskNested(s string) string {
+	b := []byte(s)
+	depth := 0
+	var quote byte
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			b[i] = ' '
+		case c == '\'' || c == '"':
+			quote = c
+			b[i] = ' '
+		case c == '(':
+			depth++
+			b[i] = ' '
+		case c == ')':
+			depth--
+			b[i] = ' '
+		case depth > 0:
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// parseTableRef parses `schema.name [as] alias`.
+func parseTableRef(ref string) (generator.Table, error) {
+	fields := strings.Fields(ref)
+	if len(fields) == 3 && strings.EqualFold(fields[1], "as") {
+		fields = []string{fields[0], fields[2]}
+	}
+	if len(fields) == 0 || len(fields) > 2 {
+		return generator.Table{}, fmt.Errorf("cannot parse table reference %q", strings.TrimSpace(ref))
+	}
+	var t generator.Table
+	if schema, name, ok := strings.Cut(fields[0], "."); ok {
+		t.Schema, t.Name = schema, name
+	} else {
+		t.Name = fields[0]
+	}
+	if len(fields) == 2 {
+		t.Alias = fields[1]
+	}
+	return t, nil
+}
+
+// parseJoin parses the text following a join keyword: `<table> [alias] on <condition>`.
+func parseJoin(keyword, rest, restMask string) (generator.Join, error) {
+	on := reOnKeyword.FindStringIndex(restMask)
+	if on == nil {
+		return generator.Join{}, fmt.Errorf("%s %s: missing on condition", keyword, strings.TrimSpace(rest))
+	}
+	target, err := parseTableRef(rest[:on[0]])
+	if err != nil {
+		return generator.Join{}, fmt.Errorf("%s: %w", keyword, err)
+	}
+	return generator.Join{
+		Type:      normalizeSpace(strings.ToLower(keyword)),
+		Target:    target,
+		Condition: strings.TrimSpace(rest[on[1]:]),
+		IsActive:  true,
+	}, nil
+}
+
+// parseColumn splits a select item into its expression and alias (explicit `as alias`,
+// or an implicit alias after a plain column reference).
+func parseColumn(item string) generator.ColumnMapping {
+	if m := reColumnAlias.FindStringSubmatch(item); m != nil {
+		return generator.ColumnMapping{Expression: m[1], Alias: m[2]}
+	}
+	if m := reImplicitAlias.FindStringSubmatch(item); m != nil && !strings.EqualFold(m[1], "distinct") {
+		return generator.ColumnMapping{Expression: m[1], Alias: m[2]}
+	}
+	return generator.ColumnMapping{Expression: item}
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"demo/steps"
+)
+
+// demoStep 返回仓库中 demo.txt 第 id 步的脚本。
+func demoStep(t *testing.T, id int) string {
+	t.Helper()
+	content, err := os.ReadFile("../demo.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	process, err := ParseDemoFile(string(content), "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range process.Steps {
+		if s.ID == id {
+			return s.Content
+		}
+	}
+	t.Fatalf("demo.txt has no step %d", id)
+	return ""
+}
+
+func TestParseHiveInitializationSQLDemo(t *testing.T) {
+	got, err := ParseHiveInitializationSQL(demoStep(t, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 被注释掉的 join 和 group by 项保留在原位置，IsActive 为 false；注释掉的 SELECT 项被丢弃。
+	want := steps.GetStep1HiveInitConfig()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseHiveInitializationSQL(step 1) =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseHiveIncrementalSQLDemo(t *testing.T) {
+	got, err := ParseHiveIncrementalSQL(demoStep(t, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PartitionClause != "dt='${mt1}'" {
+		t.Errorf("PartitionClause = %q, want dt='${mt1}'", got.PartitionClause)
+	}
+	// 第 2 步在 group by 之前多了一个 ;，它不属于 WHERE 条件，也不影响 GROUP BY。
+	if strings.Contains(got.WhereClause, ";") || !strings.HasPrefix(got.WhereClause, "s.dt='${mt1}' and ") {
+		t.Errorf("WhereClause = %q", got.WhereClause)
+	}
+	if n := len(got.GroupByColumns); n != 17 {
+		t.Fatalf("got %d group by columns, want 17", n)
+	}
+	if g := got.GroupByColumns[4]; g.Expression != "ag.AGENT_BUS_DEP_3_CODE" || g.IsActive {
+		t.Errorf("group by 5 = %+v, want inactive ag.AGENT_BUS_DEP_3_CODE", g)
+	}
+	if len(got.Joins) != 2 || !got.Joins[0].IsActive || got.Joins[1].IsActive || got.Joins[1].Target.Alias != "ag" {
+		t.Errorf("Joins = %+v, want the active T_DIM_DATE join and the commented-out t_dim_agent join", got.Joins)
+	}
+}
+
+func TestParseHiveSQLErrors(t *testing.T) {
+	tests := []struct {
+		name, sql, err string
+	}{
+		{"not an insert", "select a from t", "must start with insert overwrite table"},
+		{"union", "insert overwrite table x select a from t union all select a from u", "unsupported clause"},
+		{"no from", "insert overwrite table x select 1", "missing from clause"},
+		{"where after group by", "insert overwrite table x select a from t group by a where a > 1", "clauses must appear in the order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseHiveSQL(tt.sql); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseHiveSQL() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+	if _, err := ParseHiveInitializationSQL(demoStep(t, 2)); err == nil {
+		t.Error("ParseHiveInitializationSQL accepted a statement writing a partition")
+	}
+}
+
+func TestWriteTablesFileRoundTrip(t *testing.T) {
+	config, err := ParseHiveInitializationSQL(demoStep(t, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := ParseRemark("统计，增量(SALE_MONTH)，近2月")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := DwsTableFromSQL(config, policy)
+
+	tables, err := ParseTablesFile(WriteTablesFile([]*DwsTable{table}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("ParseTablesFile returned %d tables, want 1", len(tables))
+	}
+	if !reflect.DeepEqual(tables[0], table) {
+		t.Errorf("round trip changed the table:\n%+v\nwant\n%+v", tables[0], table)
+	}
+	if got, err := tables[0].LoadPolicy(); err != nil || got.Window != policy.Window {
+		t.Errorf("LoadPolicy() = %+v, %v, want window %+v", got, err, policy.Window)
+	}
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultPeriodParam is the run parameter that carries the business period in generated scripts.
+const DefaultPeriodParam = "mt1"
+
+// AggregationMode tells whether a DWS table is aggregated (统计) or keeps detail rows (明细).
+type AggregationMode string
+
+const (
+	AggregatedMode AggregationMode = "统计"
+	DetailMode     AggregationMode = "明细"
+)
+
+// WindowUnit is the unit of the lookback window declared in a remark.
+type WindowUnit string
+
+const (
+	WindowMonth WindowUnit = "月"
+	WindowDay   WindowUnit = "天"
+	WindowFull  WindowUnit = "全量"
+)
+
+// LoadWindow is the lookback window of an incremental load, e.g. 近2月 => {Unit: WindowMonth, Size: 2}.
+type LoadWindow struct {
+	Unit WindowUnit
+	Size int
+}
+
+// LoadPolicy is the structured form of a DwsTable remark such as "统计，增量(EX_DATE)，近2月".
+type LoadPolicy struct {
+	Aggregation    AggregationMode
+	IsIncremental  bool
+	IncrementField string
+	Window         LoadWindow
+}
+
+var (
+	reRemarkSeparator = regexp.MustCompile(`[，,、;；]`)
+	reRemarkIncrement = regexp.MustCompile(`^增量\s*(?:[（(]\s*([\w.]*)\s*[)）])?$`)
+	reRemarkWindow    = regexp.MustCompile(`^近\s*(\d+)\s*(?:个)?\s*(月|天|日|年)$`)
+)
+
+// ParseRemark parses a table remark into a LoadPolicy.
+// Tokens are separated by full-width or half-width commas and may appear in any order.
+func ParseRemark(remark string) (*LoadPolicy, error) {
+	policy := &LoadPolicy{}
+	var windowSet bool
+
+	for _, token := range reRemarkSeparator.Split(remark, -1) {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		switch {
+		case token == string(AggregatedMode) || token == string(DetailMode):
+			policy.Aggregation = AggregationMode(token)
+		case token == string(WindowFull):
+			policy.Window = LoadWindow{Unit: WindowFull}
+			windowSet = true
+		case reRemarkIncrement.MatchString(token):
+			policy.IsIncremental = true
+			policy.IncrementField = reRemarkIncrement.FindStringSubmatch(token)[1]
+		case reRemarkWindow.MatchString(token):
+			matches := reRemarkWindow.FindStringSubmatch(token)
+			size, err := strconv.Atoi(matches[1])
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("invalid window %q in remark %q", token, remark)
+			}
+			switch matches[2] {
+			case "月":
+				policy.Window = LoadWindow{Unit: WindowMonth, Size: size}
+			case "年":
+				policy.Window = LoadWindow{Unit: WindowMonth, Size: size * 12}
+			default:
+				policy.Window = LoadWindow{Unit: WindowDay, Size: size}
+			}
+			windowSet = true
+		default:
+			return nil, fmt.Errorf("unknown token %q in remark %q", token, remark)
+		}
+	}
+
+	if !windowSet {
+		policy.Window = LoadWindow{Unit: WindowFull}
+	}
+	if !policy.IsIncremental && policy.Window.Unit != WindowFull {
+		return nil, fmt.Errorf("remark %q declares a window without an increment", remark)
+	}
+	return policy, nil
+}
+
+// LoadPolicy parses the table's remark and validates it against the 增量字段 column.
+func (dt *DwsTable) LoadPolicy() (*LoadPolicy, error) {
+	policy, err := ParseRemark(dt.Remark)
+	if err != nil {
+		return nil, fmt.Errorf("table %s: %w", dt.Name, err)
+	}
+	if !policy.IsIncremental {
+		return policy, nil
+	}
+
+	switch {
+	case policy.IncrementField == "" && dt.IncrementField == "":
+		return nil, fmt.Errorf("table %s: remark is incremental but no increment field is declared", dt.Name)
+	case policy.IncrementField == "":
+		policy.IncrementField = dt.IncrementField
+	case dt.IncrementField != "" && !strings.EqualFold(policy.IncrementField, dt.IncrementField):
+		return nil, fmt.Errorf("table %s: remark increment field %s does not match 增量字段 %s",
+			dt.Name, policy.IncrementField, dt.IncrementField)
+	}
+	return policy, nil
+}
+
+// IncrementalWhereClause builds the filter selecting the policy's window from column,
+// relative to the period held by the run parameter param (yyyyMM for month windows,
+// yyyyMMdd for day windows). Outside day windows the column is compared by its first six
+// characters, so yyyyMMdd columns such as ex_date include every day of the bounding months.
+// It returns "" for policies that are not incremental.
+func (p *LoadPolicy) IncrementalWhereClause(column, param string) string {
+	if !p.IsIncremental {
+		return ""
+	}
+	placeholder := fmt.Sprintf("${%s}", param)
+	month := fmt.Sprintf("substr(%s,1,6)", column)
+
+	switch p.Window.Unit {
+	case WindowMonth:
+		return fmt.Sprintf("%s<='%s'\n    AND %s>=date_format(add_months(trunc(from_unixtime(unix_timestamp('%s','yyyyMM'),'yyyy-MM'),'MM'),%d),'yyyyMM')",
+			month, placeholder, month, placeholder, -(p.Window.Size - 1))
+	case WindowDay:
+		return fmt.Sprintf("%s<='%s'\n    AND %s>=date_format(date_sub(from_unixtime(unix_timestamp('%s','yyyyMMdd'),'yyyy-MM-dd'),%d),'yyyyMMdd')",
+			column, placeholder, column, placeholder, p.Window.Size-1)
+	default:
+		return fmt.Sprintf("%s<='%s'", month, placeholder)
+	}
+}
+
+// Periods returns the number of monthly periods, up to and including the current one, that
+// the policy's window reads: the window size for month windows, the months a day window can
+// span, and -1 for incremental loads without a window. Non-incremental policies read one period.
+func (p *LoadPolicy) Periods() int {
+	if !p.IsIncremental {
+		return 1
+	}
+	switch p.Window.Unit {
+	case WindowMonth:
+		return p.Window.Size
+	case WindowDay:
+		if p.Window.Size <= 1 {
+			return 1
+		}
+		// Every run of 28 days may reach into one more month.
+		return (p.Window.Size-2)/28 + 2
+	default:
+		return -1
+	}
+}
+
+// Remark formats the policy back into remark form, e.g. "统计，增量(EX_DATE)，近2月".
+// Month windows that are a whole number of years are still written in months, which
+// ParseRemark reads back to the same window.
+func (p *LoadPolicy) Remark() string {
+	var tokens []string
+	if p.Aggregation != "" {
+		tokens = append(tokens, string(p.Aggregation))
+	}
+	if p.IsIncremental {
+		tokens = append(tokens, fmt.Sprintf("增量(%s)", p.IncrementField))
+	}
+	switch p.Window.Unit {
+	case WindowMonth:
+		tokens = append(tokens, fmt.Sprintf("近%d月", p.Window.Size))
+	case WindowDay:
+		tokens = append(tokens, fmt.Sprintf("近%d天", p.Window.Size))
+	default:
+		if !p.IsIncremental {
+			tokens = append(tokens, string(WindowFull))
+		}
+	}
+	return strings.Join(tokens, "，")
+}
+
+var (
+	reMonthWindowFilter = regexp.MustCompile(`(?i)(?:\w+\.)?(\w+)(?:\s*,\s*1\s*,\s*6\s*\))?\s*>=\s*date_format\(\s*add_months\(.*?,\s*(-?\d+)\s*\)\s*,\s*'yyyyMM'\s*\)`)
+	reDayWindowFilter   = regexp.MustCompile(`(?i)(?:\w+\.)?(\w+)\s*>=\s*date_format\(\s*date_sub\(.*?,\s*(\d+)\s*\)\s*,\s*'yyyyMMdd'\s*\)`)
+)
+
+// InferLoadPolicy recognises the window filter produced by IncrementalWhereClause in a where
+// clause and returns the corresponding incremental policy, or nil when there is none.
+func InferLoadPolicy(where string) *LoadPolicy {
+	if m := reMonthWindowFilter.FindStringSubmatch(where); m != nil {
+		offset, _ := strconv.Atoi(m[2])
+		return &LoadPolicy{IsIncremental: true, IncrementField: m[1], Window: LoadWindow{Unit: WindowMonth, Size: 1 - offset}}
+	}
+	if m := reDayWindowFilter.FindStringSubmatch(where); m != nil {
+		days, _ := strconv.Atoi(m[2])
+		return &LoadPolicy{IsIncremental: true, IncrementField: m[1], Window: LoadWindow{Unit: WindowDay, Size: days + 1}}
+	}
+	return nil
+}
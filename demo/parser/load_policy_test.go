@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIncrementalWhereClause(t *testing.T) {
+	tests := []struct {
+		remark string
+		want   []string
+	}{
+		// ex_date 为 yyyyMMdd，按前 6 位与月份比较，当月的每一天都包含在内。
+		{"统计，增量(EX_DATE)，近2月", []string{"substr(s.EX_DATE,1,6)<='${mt1}'", "substr(s.EX_DATE,1,6)>=date_format(", "'MM'),-1),'yyyyMM')"}},
+		{"统计，增量(EX_DATE)", []string{"substr(s.EX_DATE,1,6)<='${mt1}'"}},
+		{"明细，增量(FLT_DATE)，近7天", []string{"s.FLT_DATE<='${mt1}'", "s.FLT_DATE>=date_format(date_sub(", "'yyyy-MM-dd'),6),'yyyyMMdd')"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remark, func(t *testing.T) {
+			policy, err := ParseRemark(tt.remark)
+			if err != nil {
+				t.Fatal(err)
+			}
+			where := policy.IncrementalWhereClause("s."+policy.IncrementField, DefaultPeriodParam)
+			for _, want := range tt.want {
+				if !strings.Contains(where, want) {
+					t.Errorf("IncrementalWhereClause() = %q, want it to contain %q", where, want)
+				}
+			}
+			if policy.Window.Unit == WindowFull {
+				return
+			}
+			inferred := InferLoadPolicy(where)
+			if inferred == nil || inferred.Window != policy.Window || inferred.IncrementField != policy.IncrementField {
+				t.Errorf("InferLoadPolicy(%q) = %+v, want window %+v", where, inferred, policy.Window)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyPeriods(t *testing.T) {
+	tests := []struct {
+		remark string
+		want   int
+	}{
+		{"统计，全量", 1},
+		{"统计，增量(EX_DATE)", -1},
+		{"统计，增量(EX_DATE)，近2月", 2},
+		{"统计，增量(EX_DATE)，近1年", 12},
+		{"明细，增量(FLT_DATE)，近1天", 1},
+		{"明细，增量(FLT_DATE)，近7天", 2},
+		{"明细，增量(FLT_DATE)，近30天", 3},
+	}
+	for _, tt := range tests {
+		policy, err := ParseRemark(tt.remark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := policy.Periods(); got != tt.want {
+			t.Errorf("Periods(%q) = %d, want %d", tt.remark, got, tt.want)
+		}
+	}
+}
@@ -2,23 +2,43 @@ package parser
 
 import (
 	"demo/model"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+)
+
+// 步骤标题下可选的元数据行，例如 "@depends-on: 3, 5"。
+const (
+	MetaCommand    = "command"
+	MetaDatasource = "datasource"
+	MetaDependsOn  = "depends-on"
+	MetaRetries    = "retries"
+	MetaBackoff    = "backoff"
+	MetaTimeout    = "timeout"
+)
+
+var (
+	// 用于解析每个步骤标题的正则表达式，例如 "1. hive sql（初始化）："
+	// 它可以同时处理全角和半角的括号。
+	headerRegex = regexp.MustCompile(`^(\d+)\.\s*(.+?)\s*[（(](.+?)[)）]：`)
+	// 用于解析元数据行的正则表达式，例如 "@retries: 2"，冒号可以是全角或半角。
+	metaRegex = regexp.MustCompile(`^@([\w-]+)\s*[:：]\s*(.*)$`)
 )
 
 // ParseDemoFile 解析 demo.txt 文件的内容并返回一个 DemoProcess 对象。
-func ParseDemoFile(content string, processName string) *model.DemoProcess {
+// 每个步骤块由标题行、可选的元数据行和步骤内容组成。标题行之后可以跟若干
+// "@command: hivesql"、"@datasource: dm8_pro"、"@depends-on: 3, 5"、"@retries: 2"、
+// "@backoff: 5m"、"@timeout: 2h" 形式的元数据行，空一行后是步骤内容。
+// 标题不符合格式、元数据无法识别或取值无效的块会返回错误，错误中包含块的序号和标题行。
+func ParseDemoFile(content string, processName string) (*model.DemoProcess, error) {
 	var steps []model.Step
 
 	// 使用分隔符将内容分割成每个步骤的块。
-	stepBlocks := strings.Split(content, "----------------------------")
+	stepBlocks := strings.Split(content, StepSeparator)
 
-	// 用于解析每个步骤标题的正则表达式，例如 "1. hive sql（初始化）："
-	// 它可以同时处理全角和半角的括号。
-	headerRegex := regexp.MustCompile(`^(\d+)\.\s*(.+?)\s*[（(](.+?)[)）]：`)
-
-	for _, block := range stepBlocks {
+	for i, block := range stepBlocks {
 		block = strings.TrimSpace(block)
 		if block == "" {
 			continue
@@ -26,33 +46,32 @@ func ParseDemoFile(content string, processName string) *model.DemoProcess {
 
 		// 每个块都有一个标题行和随后的内容。
 		lines := strings.SplitN(block, "\n", 2)
-		if len(lines) == 0 {
-			continue
-		}
-		header := lines[0]
-
-		var stepContent string
-		if len(lines) > 1 {
-			stepContent = strings.TrimSpace(lines[1])
-		}
+		header := strings.TrimSpace(lines[0])
 
 		matches := headerRegex.FindStringSubmatch(header)
 		if len(matches) != 4 {
-			// 如果块不符合预期的步骤格式，则跳过。
-			continue
+			return nil, fmt.Errorf("第 %d 个步骤块的标题 %q 不符合“N. 名称（加载类型）：”格式", i+1, header)
 		}
 
 		id, err := strconv.Atoi(matches[1])
 		if err != nil {
-			// 如果ID不是有效的数字，则跳过。
-			continue
+			return nil, fmt.Errorf("第 %d 个步骤块的标题 %q 中的 ID 无效: %w", i+1, header, err)
 		}
 
 		step := model.Step{
-			ID:      id,
-			Name:    strings.TrimSpace(matches[2]),
-			Load:    model.LoadType(strings.TrimSpace(matches[3])),
-			Content: stepContent,
+			ID:   id,
+			Name: strings.TrimSpace(matches[2]),
+			Load: model.LoadType(strings.TrimSpace(matches[3])),
+		}
+
+		var body string
+		if len(lines) > 1 {
+			body = lines[1]
+		}
+		meta, stepContent := splitMetadata(body)
+		step.Content = strings.TrimSpace(stepContent)
+		if err := applyMetadata(&step, meta); err != nil {
+			return nil, fmt.Errorf("步骤 %d（%s）: %w", id, header, err)
 		}
 
 		steps = append(steps, step)
@@ -63,5 +82,110 @@ func ParseDemoFile(content string, processName string) *model.DemoProcess {
 		Steps: steps,
 	}
 
-	return process
+	return process, nil
+}
+
+// metaLine 是一条元数据行。
+type metaLine struct {
+	key, value string
+}
+
+// splitMetadata 将步骤标题之后开头连续的元数据行（允许夹杂空行）与步骤内容分开。
+func splitMetadata(body string) ([]metaLine, string) {
+	var meta []metaLine
+	rest := body
+	for rest != "" {
+		line, next, _ := strings.Cut(rest, "\n")
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" {
+			m := metaRegex.FindStringSubmatch(trimmed)
+			if m == nil {
+				break
+			}
+			meta = append(meta, metaLine{key: strings.ToLower(m[1]), value: strings.TrimSpace(m[2])})
+		}
+		rest = next
+	}
+	return meta, rest
+}
+
+// applyMetadata 将元数据写入步骤。重试相关的元数据只覆盖指定的项，其余项取步骤的默认策略。
+func applyMetadata(step *model.Step, meta []metaLine) error {
+	var retries, backoff, timeout *string
+	seen := make(map[string]bool)
+	for _, m := range meta {
+		if seen[m.key] {
+			return fmt.Errorf("元数据 @%s 重复", m.key)
+		}
+		seen[m.key] = true
+
+		value := m.value
+		switch m.key {
+		case MetaCommand:
+			switch ct := model.CommandType(value); ct {
+			case model.HiveSQLCommand, model.ShellCommand, model.DMProcCommand:
+				step.CommandType = ct
+			default:
+				return fmt.Errorf("@%s 的取值 %q 无效，应为 hivesql、shell 或 dm_proc", m.key, value)
+			}
+		case MetaDatasource:
+			step.Datasource = value
+		case MetaDependsOn:
+			deps, err := parseIDList(value)
+			if err != nil {
+				return fmt.Errorf("@%s: %w", m.key, err)
+			}
+			step.DependsOn = deps
+		case MetaRetries:
+			retries = &value
+		case MetaBackoff:
+			backoff = &value
+		case MetaTimeout:
+			timeout = &value
+		default:
+			return fmt.Errorf("无法识别的元数据 @%s", m.key)
+		}
+	}
+
+	if retries == nil && backoff == nil && timeout == nil {
+		return nil
+	}
+	// 默认策略依赖命令类型和内容，因此在其余元数据处理完之后再计算。
+	policy := model.DefaultPolicy(*step)
+	if retries != nil {
+		n, err := strconv.Atoi(*retries)
+		if err != nil || n < 0 {
+			return fmt.Errorf("@%s 的取值 %q 应为非负整数", MetaRetries, *retries)
+		}
+		policy.Retries = n
+	}
+	for _, d := range []struct {
+		key   string
+		value *string
+		dst   *time.Duration
+	}{{MetaBackoff, backoff, &policy.Backoff}, {MetaTimeout, timeout, &policy.Timeout}} {
+		if d.value == nil {
+			continue
+		}
+		v, err := time.ParseDuration(*d.value)
+		if err != nil || v < 0 {
+			return fmt.Errorf("@%s 的取值 %q 应为时长，例如 30m、2h", d.key, *d.value)
+		}
+		*d.dst = v
+	}
+	step.Policy = &policy
+	return nil
+}
+
+// parseIDList 解析逗号分隔的步骤 ID 列表，例如 "3, 5"。
+func parseIDList(value string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '，' || r == ' ' }) {
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是有效的步骤 ID", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
 }
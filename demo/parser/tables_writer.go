@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"demo/generator"
+)
+
+var (
+	reQualifiedColumn = regexp.MustCompile(`\b(\w+)\.(\w+)\b`)
+	reColumnRef       = regexp.MustCompile(`^(?:\w+\.)?(\w+)$`)
+	reCountCall       = regexp.MustCompile(`(?i)^count\s*\(`)
+	reSumCall         = regexp.MustCompile(`(?i)^(sum|avg)\s*\(`)
+)
+
+// DwsTableFromSQL builds the tables.txt description of a structured Hive query: one field per
+// select column, with 来源表 resolved from the table alias used in the expression and
+// 字段逻辑 stripped of those aliases, which is the form ToHiveSQLConfig expects.
+// policy supplies the remark and 增量字段; when nil the table is described as a full load.
+// Columns that cannot be described (no alias) are skipped and reported in the returned
+// warnings, as are expressions reading from more than one table.
+func DwsTableFromSQL(config *generator.HiveInitializationSQL, policy *LoadPolicy) (*DwsTable, []string) {
+	aliases := map[string]string{}
+	if config.FromTable.Alias != "" {
+		aliases[config.FromTable.Alias] = config.FromTable.Name
+	}
+	for _, j := range config.Joins {
+		if j.Target.Alias != "" {
+			aliases[j.Target.Alias] = j.Target.Name
+		}
+	}
+
+	table := &DwsTable{Name: config.TargetTable.Name, SourceSheet: config.TargetTable.Name}
+	var warnings []string
+	aggregated := false
+	for i, col := range config.SelectColumns {
+		name := col.Alias
+		if name == "" {
+			if m := reColumnRef.FindStringSubmatch(col.Expression); m != nil {
+				name = m[1]
+			} else {
+				warnings = append(warnings, fmt.Sprintf("select column %d (%s) has no alias and was skipped", i+1, col.Expression))
+				continue
+			}
+		}
+
+		field := Field{Name: name, Type: fieldType(col.Expression)}
+		var sources []string
+		seen := map[string]bool{}
+		logic := replaceOutsideQuotes(col.Expression, func(segment string) string {
+			return reQualifiedColumn.ReplaceAllStringFunc(segment, func(m string) string {
+				parts := reQualifiedColumn.FindStringSubmatch(m)
+				source, ok := aliases[parts[1]]
+				if !ok {
+					return m
+				}
+				if !seen[source] {
+					seen[source] = true
+					sources = append(sources, source)
+				}
+				return parts[2]
+			})
+		})
+		if len(sources) > 0 {
+			field.SourceTable = sources[0]
+		}
+		if len(sources) > 1 {
+			warnings = append(warnings, fmt.Sprintf("field %s reads from %s; 来源表 set to %s", name, strings.Join(sources, ", "), sources[0]))
+		}
+		if logic != "''" {
+			field.Logic = logic
+		}
+		if isAggregate(logic) {
+			aggregated = true
+		}
+		table.Fields = append(table.Fields, field)
+	}
+
+	if policy == nil {
+		policy = &LoadPolicy{Window: LoadWindow{Unit: WindowFull}}
+	}
+	p := *policy
+	if p.Aggregation == "" {
+		p.Aggregation = DetailMode
+		if aggregated {
+			p.Aggregation = AggregatedMode
+		}
+	}
+	table.Remark = p.Remark()
+	table.IncrementField = strings.ToLower(p.IncrementField)
+	for i := range table.Fields {
+		if p.IncrementField != "" && strings.EqualFold(table.Fields[i].Name, p.IncrementField) {
+			table.Fields[i].Remark = "增量字段"
+		}
+	}
+	return table, warnings
+}
+
+// fieldType guesses the column type from its expression: counts are DECIMAL(22,0), sums and
+// averages DECIMAL(22,3), everything else string.
+func fieldType(expr string) string {
+	switch {
+	case reCountCall.MatchString(expr):
+		return "DECIMAL(22,0)"
+	case reSumCall.MatchString(expr):
+		return "DECIMAL(22,3)"
+	default:
+		return "string"
+	}
+}
+
+// replaceOutsideQuotes applies fn to the parts of s outside single-quoted string literals.
+func replaceOutsideQuotes(s string, fn func(string) string) string {
+	var sb strings.Builder
+	for i, part := range strings.Split(s, "'") {
+		if i > 0 {
+			sb.WriteString("'")
+		}
+		if i%2 == 0 {
+			part = fn(part)
+		}
+		sb.WriteString(part)
+	}
+	return sb.String()
+}
+
+// WriteTablesFile renders tables in the "DWS表对象" section format of tables.txt, which
+// ParseTablesFile reads back.
+func WriteTablesFile(tables []*DwsTable) string {
+	var sb strings.Builder
+	sb.WriteString("========== DWS表对象 ==========\n\n")
+	for i, t := range tables {
+		fmt.Fprintf(&sb, "【DWS表 %d】\n", i+1)
+		fmt.Fprintf(&sb, "事实表详情Sheet页名: %s\n", t.SourceSheet)
+		sb.WriteString("事实表名称: \n")
+		fmt.Fprintf(&sb, "表英文名: %s\n", t.Name)
+		fmt.Fprintf(&sb, "备注: %s\n", t.Remark)
+		fmt.Fprintf(&sb, "增量字段: %s\n", t.IncrementField)
+		fmt.Fprintf(&sb, "字段数量: %d\n\n", len(t.Fields))
+		sb.WriteString("字段详情:\n")
+		for j, f := range t.Fields {
+			fmt.Fprintf(&sb, "  [字段 %d]\n", j+1)
+			fmt.Fprintf(&sb, "    字段名: %s\n", f.Name)
+			fmt.Fprintf(&sb, "    字段类型: %s\n", f.Type)
+			fmt.Fprintf(&sb, "    来源表: %s\n", f.SourceTable)
+			fmt.Fprintf(&sb, "    字段逻辑: %s\n", f.Logic)
+			fmt.Fprintf(&sb, "    备注: %s\n\n", f.Remark)
+		}
+		sb.WriteString("------------------------------\n\n")
+	}
+	sb.WriteString("==============================\n")
+	return sb.String()
+}
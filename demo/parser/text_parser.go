@@ -37,6 +37,7 @@ func ParseTablesFile(content string) ([]*DwsTable, error) {
 	reKeyValue := regexp.MustCompile(`^([\p{Han}\w\s]+):\s*(.*)`)
 	reFieldHeader := regexp.MustCompile(`^\[字段 (\d+)]`)
 	reFieldName := regexp.MustCompile(`^\s+字段名:\s*(.*)`)
+	reFieldType := regexp.MustCompile(`^\s+字段类型:\s*(.*)`)
 	reFieldLogic := regexp.MustCompile(`^\s+字段逻辑:\s*(.*)`)
 	reFieldSource := regexp.MustCompile(`^\s+来源表:\s*(.*)`)
 
@@ -63,6 +64,8 @@ func ParseTablesFile(content string) ([]*DwsTable, error) {
 					lastField := &currentTable.Fields[len(currentTable.Fields)-1]
 					if matches := reFieldName.FindStringSubmatch(line); len(matches) > 1 {
 						lastField.Name = strings.TrimSpace(matches[1])
+					} else if matches := reFieldType.FindStringSubmatch(line); len(matches) > 1 {
+						lastField.Type = strings.TrimSpace(matches[1])
 					} else if matches := reFieldLogic.FindStringSubmatch(line); len(matches) > 1 {
 						lastField.Logic = strings.TrimSpace(matches[1])
 					} else if matches := reFieldSource.FindStringSubmatch(line); len(matches) > 1 {
@@ -187,6 +190,79 @@ func (dt *DwsTable) ToHiveSQLConfig() *generator.HiveInitializationSQL {
 	return config
 }
 
+// ToHiveIncrementalSQLConfig builds the incremental variant of ToHiveSQLConfig: it writes the
+// ${mt1} partition of the DWS table and filters the source by the window declared in the remark.
+func (dt *DwsTable) ToHiveIncrementalSQLConfig() (*generator.HiveIncrementalSQL, error) {
+	policy, err := dt.LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	if !policy.IsIncremental {
+		return nil, fmt.Errorf("table %s is not incremental (remark %q)", dt.Name, dt.Remark)
+	}
+
+	initConfig := dt.ToHiveSQLConfig()
+	if initConfig == nil {
+		return nil, fmt.Errorf("table %s has no source table", dt.Name)
+	}
+
+	config := &generator.HiveIncrementalSQL{
+		TargetTable:     generator.IncrTable{Schema: initConfig.TargetTable.Schema, Name: initConfig.TargetTable.Name},
+		PartitionClause: fmt.Sprintf("dt='${%s}'", DefaultPeriodParam),
+		FromTable: generator.IncrTable{
+			Schema: initConfig.FromTable.Schema,
+			Name:   initConfig.FromTable.Name,
+			Alias:  initConfig.FromTable.Alias,
+		},
+		WhereClause:   policy.IncrementalWhereClause(initConfig.FromTable.Alias+"."+policy.IncrementField, DefaultPeriodParam),
+		SourcePeriods: policy.Periods(),
+	}
+	for _, col := range initConfig.SelectColumns {
+		config.SelectColumns = append(config.SelectColumns, generator.IncrColumnMapping{Expression: col.Expression, Alias: col.Alias})
+	}
+	for _, j := range initConfig.Joins {
+		config.Joins = append(config.Joins, generator.IncrJoin{
+			Type:      j.Type,
+			Target:    generator.IncrTable{Schema: j.Target.Schema, Name: j.Target.Name, Alias: j.Target.Alias},
+			Condition: j.Condition,
+			IsActive:  j.IsActive,
+		})
+	}
+	for _, col := range initConfig.GroupByColumns {
+		config.GroupByColumns = append(config.GroupByColumns, generator.IncrGroupByColumn{Expression: col.Expression, IsActive: col.IsActive})
+	}
+
+	return config, nil
+}
+
+// ExportFields returns the table's output columns with their declared types, used to
+// derive the HDFS export select list.
+func (dt *DwsTable) ExportFields() []generator.ExportField {
+	fields := make([]generator.ExportField, 0, len(dt.Fields))
+	for _, f := range dt.Fields {
+		fields = append(fields, generator.ExportField{Name: f.Name, Type: f.Type})
+	}
+	return fields
+}
+
+// ToHiveToHdfsConfig builds the full-table HDFS export of the DWS table.
+func (dt *DwsTable) ToHiveToHdfsConfig() *generator.HiveToHdfsScript {
+	return generator.NewHiveToHdfsScript(dt.Name, dt.ExportFields(), DefaultPeriodParam)
+}
+
+// ToHiveToHdfsIncrConfig builds the HDFS export of the ${mt1} partition written by
+// ToHiveIncrementalSQLConfig.
+func (dt *DwsTable) ToHiveToHdfsIncrConfig() (*generator.HiveToHdfsIncrScript, error) {
+	policy, err := dt.LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	if !policy.IsIncremental {
+		return nil, fmt.Errorf("table %s is not incremental (remark %q)", dt.Name, dt.Remark)
+	}
+	return generator.NewHiveToHdfsIncrScript(dt.Name, dt.ExportFields(), DefaultPeriodParam, ""), nil
+}
+
 // NEW helper function to pre-scan for raw columns inside aggregate functions
 func getAggregatedRawColumns(fields []Field) map[string]struct{} {
 	rawCols := make(map[string]struct{})
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"demo/model"
+)
+
+// StepSeparator 是 demo.txt 中分隔各步骤的行。
+const StepSeparator = "----------------------------"
+
+// WriteDemoFile 将流程按 demo.txt 的格式输出：每个步骤以“N. 名称（加载类型）：”开头，
+// 随后是步骤声明了的元数据行（命令类型、数据源、依赖和重试策略），空一行后是步骤内容，
+// 步骤之间用 StepSeparator 分隔。
+// 步骤内容首尾的空白会被去掉（ParseDemoFile 同样会去掉）；demo.txt 中没有位置记录的字段
+// （流程名、参数和失败处理步骤）不会输出。
+// 如果某个步骤写出后无法被 ParseDemoFile 原样读回，则返回错误。
+func WriteDemoFile(process *model.DemoProcess) (string, error) {
+	var sb strings.Builder
+	for _, step := range process.Steps {
+		if err := checkWritable(step); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%d. %s（%s）：\n", step.ID, step.Name, step.Load)
+		writeMetadata(&sb, step)
+		sb.WriteString("\n")
+		if content := strings.TrimSpace(step.Content); content != "" {
+			sb.WriteString(content)
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(StepSeparator)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// checkWritable 检查步骤写出后能否被 ParseDemoFile 的标题正则和分隔符原样解析。
+func checkWritable(step model.Step) error {
+	name, load := step.Name, string(step.Load)
+	switch {
+	case step.ID < 0:
+		return fmt.Errorf("步骤 %d: ID 不能为负数", step.ID)
+	case name == "" || name != strings.TrimSpace(name):
+		return fmt.Errorf("步骤 %d: 名称 %q 不能为空，首尾也不能有空白", step.ID, name)
+	case strings.ContainsAny(name, "（(\n"):
+		return fmt.Errorf("步骤 %d: 名称 %q 不能包含括号或换行", step.ID, name)
+	case load == "" || load != strings.TrimSpace(load):
+		return fmt.Errorf("步骤 %d: 加载类型 %q 不能为空，首尾也不能有空白", step.ID, load)
+	case strings.ContainsAny(load, "）)\n"):
+		return fmt.Errorf("步骤 %d: 加载类型 %q 不能包含括号或换行", step.ID, load)
+	case strings.Contains(step.Content, StepSeparator):
+		return fmt.Errorf("步骤 %d: 内容中包含分隔符 %s", step.ID, StepSeparator)
+	case metaRegex.MatchString(strings.TrimSpace(strings.SplitN(strings.TrimSpace(step.Content), "\n", 2)[0])):
+		return fmt.Errorf("步骤 %d: 内容的第一行会被当作元数据行读回", step.ID)
+	case strings.ContainsAny(step.Datasource, "\n"):
+		return fmt.Errorf("步骤 %d: 数据源 %q 不能包含换行", step.ID, step.Datasource)
+	}
+	return nil
+}
+
+// writeMetadata 输出步骤声明了的元数据行，未声明的项不输出，读回时同样保持未声明。
+func writeMetadata(sb *strings.Builder, step model.Step) {
+	if step.CommandType != "" {
+		fmt.Fprintf(sb, "@%s: %s\n", MetaCommand, step.CommandType)
+	}
+	if step.Datasource != "" {
+		fmt.Fprintf(sb, "@%s: %s\n", MetaDatasource, step.Datasource)
+	}
+	if len(step.DependsOn) > 0 {
+		ids := make([]string, len(step.DependsOn))
+		for i, id := range step.DependsOn {
+			ids[i] = strconv.Itoa(id)
+		}
+		fmt.Fprintf(sb, "@%s: %s\n", MetaDependsOn, strings.Join(ids, ", "))
+	}
+	if p := step.Policy; p != nil {
+		fmt.Fprintf(sb, "@%s: %d\n", MetaRetries, p.Retries)
+		fmt.Fprintf(sb, "@%s: %s\n", MetaBackoff, formatDuration(p.Backoff))
+		fmt.Fprintf(sb, "@%s: %s\n", MetaTimeout, formatDuration(p.Timeout))
+	}
+}
+
+// formatDuration 去掉 time.Duration 字符串末尾多余的零值单位，例如 2h0m0s 输出为 2h。
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// CheckRoundTrip 将流程写出后重新解析，确认得到的步骤与原流程一致
+// （内容按首尾去空白比较，空的依赖列表与未声明依赖视为相同）。
+func CheckRoundTrip(process *model.DemoProcess) error {
+	text, err := WriteDemoFile(process)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseDemoFile(text, process.Name)
+	if err != nil {
+		return fmt.Errorf("写出的内容无法读回: %w", err)
+	}
+
+	if len(parsed.Steps) != len(process.Steps) {
+		return fmt.Errorf("写出 %d 个步骤，读回 %d 个", len(process.Steps), len(parsed.Steps))
+	}
+	for i, want := range process.Steps {
+		want.Content = strings.TrimSpace(want.Content)
+		if len(want.DependsOn) == 0 {
+			want.DependsOn = nil
+		}
+		if got := parsed.Steps[i]; !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("步骤 %d 读回后不一致: %+v", want.ID, got)
+		}
+	}
+	return nil
+}
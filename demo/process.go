@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"demo/generator"
+	"demo/model"
+	"demo/params"
+	"demo/parser"
+)
+
+// stepLoadTypes maps the ETLProcess step types onto the load types used by the model package.
+var stepLoadTypes = map[string]model.LoadType{
+	"initialization": model.InitializationLoad,
+	"incremental":    model.IncrementalLoad,
+}
+
+// Generate renders the structured query in the same layout as demo.txt.
+func (h HiveSQLScript) Generate() string {
+	var sb strings.Builder
+
+	sb.WriteString("insert overwrite table ")
+	sb.WriteString(qualifiedName(h.ResultTable))
+	sb.WriteString("\nselect\n")
+	for i, f := range h.SelectFields {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(f.SourceExpression)
+		if f.Alias != "" {
+			sb.WriteString(" as ")
+			sb.WriteString(f.Alias)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("from ")
+	sb.WriteString(qualifiedName(h.FromTable))
+	sb.WriteString(" ")
+	sb.WriteString(h.FromTable.Alias)
+	sb.WriteString("\n")
+	for _, j := range h.Joins {
+		sb.WriteString(fmt.Sprintf("%s %s %s on %s\n", j.Type, qualifiedName(j.Table), j.Table.Alias, j.OnCondition))
+	}
+
+	if h.WhereClause != "" {
+		sb.WriteString("where ")
+		sb.WriteString(h.WhereClause)
+		sb.WriteString("\n")
+	}
+
+	if len(h.GroupByFields) > 0 {
+		sb.WriteString("group by\n")
+		sb.WriteString(strings.Join(h.GroupByFields, "\n,"))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func qualifiedName(t TableName) string {
+	if t.Schema != "" {
+		return t.Schema + "." + t.Name
+	}
+	return t.Name
+}
+
+// renderScript turns a step's Script into text. Scripts are either plain strings or
+// generator objects exposing Generate/GenerateSQL; objects that can validate themselves,
+// such as stored procedure calls, are validated first.
+func renderScript(script interface{}) (string, error) {
+	if v, ok := script.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return "", err
+		}
+	}
+	switch s := script.(type) {
+	case string:
+		return s, nil
+	case interface{ Generate() string }:
+		return s.Generate(), nil
+	case interface{ GenerateSQL() string }:
+		return s.GenerateSQL(), nil
+	default:
+		return "", fmt.Errorf("unsupported script type %T", script)
+	}
+}
+
+// toModelProcess converts an ETLProcess into the model representation used by the
+// params, runner and parser packages.
+func toModelProcess(p ETLProcess) (*model.DemoProcess, error) {
+	process := &model.DemoProcess{Name: p.Name, Params: p.Params}
+	for _, s := range p.Steps {
+		load, ok := stepLoadTypes[s.Type]
+		if !ok {
+			return nil, fmt.Errorf("step %d: unknown step type %q", s.ID, s.Type)
+		}
+		content, err := renderScript(s.Script)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", s.ID, err)
+		}
+		process.Steps = append(process.Steps, model.Step{
+			ID:          s.ID,
+			Name:        s.Name,
+			Load:        load,
+			CommandType: model.CommandType(s.CommandType),
+			DependsOn:   s.DependsOn,
+			Policy:      s.Policy,
+			Content:     content,
+		})
+	}
+	return process, nil
+}
+
+// loadProcess reads a demo.txt style file, or falls back to the built-in DemoProcess
+// when no file is given, and prepares it for execution or export.
+// Shared staging directories are rewritten to run-scoped ones, Hive steps calling
+// functions of udfs get the statements registering them, and processes without
+// declared on-failure steps get cleanup handlers generated from their staging
+// directories and MID tables. Commands analysing what the user wrote use readProcess.
+func loadProcess(file string, udfs generator.UDFRegistry) (*model.DemoProcess, error) {
+	process, err := readProcess(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewrite shared /tmp/hive/hive/<table> staging dirs into per-load, per-period, per-run ones.
+	generator.ScopeStagingDirs(process, generator.DefaultStagingRoot, params.Declared(process)[0].Name)
+	generator.AddUDFPreambles(process, udfs)
+	if len(process.OnFailure) == 0 {
+		process.OnFailure = generator.BuildFailureHandlers(process, false)
+	}
+	return process, nil
+}
+
+// readProcess reads a demo.txt style file, or converts the built-in DemoProcess when no
+// file is given, without any of the rewrites applied by loadProcess.
+func readProcess(file string) (*model.DemoProcess, error) {
+	if file == "" {
+		return toModelProcess(DemoProcess)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseDemoFile(string(content), processNameFromFile(file))
+}
+
+func processNameFromFile(file string) string {
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
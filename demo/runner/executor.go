@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"demo/model"
+)
+
+// 命令模板中的占位符：{file} 替换为写有脚本内容的临时文件路径，{script} 替换为脚本内容本身。
+// 模板中两者都没有出现时，脚本内容通过标准输入传给命令。
+const (
+	filePlaceholder   = "{file}"
+	scriptPlaceholder = "{script}"
+)
+
+// Result 记录单个步骤的执行结果。
+type Result struct {
+	StepID      int               `json:"stepId"`
+	Name        string            `json:"name"`
+	CommandType model.CommandType `json:"commandType"`
+	Command     string            `json:"command"`
+	Stdout      string            `json:"stdout,omitempty"`
+	Stderr      string            `json:"stderr,omitempty"`
+	ExitCode    int               `json:"exitCode"`
+	Attempts    int               `json:"attempts"`
+	Duration    time.Duration     `json:"duration"`
+	Err         error             `json:"-"`
+}
+
+// Succeeded 判断步骤是否执行成功。
+func (r Result) Succeeded() bool {
+	return r.Err == nil && r.ExitCode == 0
+}
+
+// Executor 负责执行某一种 CommandType 的步骤脚本。
+type Executor interface {
+	// Describe 返回执行脚本时实际调用的命令行，用于 dry-run 和执行日志。
+	Describe(script string) string
+	// Execute 执行脚本并返回结果，ctx 取消时应终止正在运行的进程。
+	Execute(ctx context.Context, script string) Result
+}
+
+// CommandExecutor 通过 os/exec 调用外部命令执行脚本，例如 bash、beeline 或达梦的 disql。
+type CommandExecutor struct {
+	// Args 是命令模板，第一个元素为可执行文件，可以包含 {file} 或 {script} 占位符。
+	Args []string
+}
+
+// NewCommandExecutor 将形如 "beeline -u jdbc:hive2://... -f {file}" 的命令行拆分为命令模板。
+func NewCommandExecutor(commandLine string) *CommandExecutor {
+	return &CommandExecutor{Args: strings.Fields(commandLine)}
+}
+
+// usesPlaceholder 判断命令模板中是否出现了指定的占位符。
+func (e *CommandExecutor) usesPlaceholder(placeholder string) bool {
+	for _, arg := range e.Args {
+		if strings.Contains(arg, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// expand 用文件路径和脚本内容替换命令模板中的占位符。
+func (e *CommandExecutor) expand(file, script string) []string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		arg = strings.ReplaceAll(arg, filePlaceholder, file)
+		args[i] = strings.ReplaceAll(arg, scriptPlaceholder, script)
+	}
+	return args
+}
+
+// Describe 返回替换占位符后的命令行；{script} 会以省略形式显示，避免日志被整段脚本淹没。
+func (e *CommandExecutor) Describe(script string) string {
+	return strings.Join(e.expand("<script file>", "<script>"), " ")
+}
+
+// Execute 运行命令，收集标准输出、标准错误、退出码和耗时。
+func (e *CommandExecutor) Execute(ctx context.Context, script string) Result {
+	result := Result{Command: e.Describe(script)}
+	if len(e.Args) == 0 {
+		result.Err = errors.New("没有配置执行命令")
+		result.ExitCode = -1
+		return result
+	}
+
+	var file string
+	if e.usesPlaceholder(filePlaceholder) {
+		f, err := os.CreateTemp("", "workflow-step-*")
+		if err != nil {
+			result.Err = err
+			result.ExitCode = -1
+			return result
+		}
+		file = f.Name()
+		defer os.Remove(file)
+		_, err = f.WriteString(script)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			result.Err = err
+			result.ExitCode = -1
+			return result
+		}
+	}
+
+	args := e.expand(file, script)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	if !e.usesPlaceholder(filePlaceholder) && !e.usesPlaceholder(scriptPlaceholder) {
+		cmd.Stdin = strings.NewReader(script)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Err = err
+	default:
+		result.ExitCode = -1
+		result.Err = err
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil && result.Err != nil {
+		result.Err = ctxErr
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TableLock 是基于锁文件的目标表互斥锁，防止同一张目标表上的多个运行同时执行。
+type TableLock struct {
+	paths []string
+}
+
+// AcquireTableLocks 为每张目标表创建锁文件（O_EXCL 保证原子性）。
+// 任意一张表已被锁定时，释放已获得的锁并返回包含持有者信息的错误。
+func AcquireTableLocks(dir, runID string, tables []string) (*TableLock, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	lock := &TableLock{}
+	for _, table := range tables {
+		path := filepath.Join(dir, strings.ToUpper(table)+".lock")
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			lock.Release()
+			if errors.Is(err, os.ErrExist) {
+				holder, _ := os.ReadFile(path)
+				return nil, fmt.Errorf("目标表 %s 正在被其他运行使用（%s）；如确认该运行已结束，请删除锁文件 %s",
+					table, strings.TrimSpace(string(holder)), path)
+			}
+			return nil, err
+		}
+		fmt.Fprintf(f, "run=%s pid=%d since=%s\n", runID, os.Getpid(), time.Now().Format(time.RFC3339))
+		f.Close()
+		lock.paths = append(lock.paths, path)
+	}
+	return lock, nil
+}
+
+// Release 删除本次获得的所有锁文件。
+func (l *TableLock) Release() {
+	for _, path := range l.paths {
+		os.Remove(path)
+	}
+	l.paths = nil
+}
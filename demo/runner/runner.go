@@ -0,0 +1,487 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"demo/generator"
+	"demo/model"
+	"demo/params"
+)
+
+// 默认的执行命令模板，可以通过 Config 覆盖。
+const (
+	DefaultShellCommand = "bash -c {script}"
+	DefaultHiveCommand  = "beeline -f {file}"
+)
+
+// DefaultFailureHandlerTimeout 为全部失败处理步骤默认的总时限。
+const DefaultFailureHandlerTimeout = 2 * time.Minute
+
+// Config 描述各 CommandType 使用的执行命令模板。
+type Config struct {
+	ShellCommand string
+	HiveCommand  string
+	// DMCommand 为执行达梦 SQL 的客户端命令，例如 "disql SYSDBA/***@host:5236 `{file}"。
+	// 达梦连接信息因环境而异，因此没有默认值。
+	DMCommand string
+}
+
+// Runner 按依赖关系执行流程中的步骤，并根据 CommandType 将每一步分派给对应的 Executor。
+type Runner struct {
+	Executors map[model.CommandType]Executor
+	// DryRun 为 true 时只打印解析后的命令和脚本，不真正执行。
+	DryRun bool
+	// Out 用于输出执行日志，为 nil 时不输出。
+	Out io.Writer
+	// State 用于持久化每次运行的步骤状态，为 nil 时不记录，也无法续跑。
+	State *StateStore
+	// MaxParallel 为同一层中可以并行执行的最大步骤数，小于 1 时按 1 处理（串行）。
+	MaxParallel int
+	// HdfsSafety 限制 shell 步骤中 hdfs dfs 命令可以删除或修改的路径，为 nil 时不检查。
+	HdfsSafety *generator.HdfsSafety
+	// FailureHandlerTimeout 为全部失败处理步骤的总时限，小于等于 0 时使用 DefaultFailureHandlerTimeout。
+	FailureHandlerTimeout time.Duration
+	// Abort 关闭时停止正在执行的失败处理步骤（例如收到第二次中断），为 nil 时只受时限约束。
+	Abort <-chan struct{}
+
+	logMu sync.Mutex
+}
+
+// New 根据配置创建 Runner，未配置的命令使用默认模板。
+func New(cfg Config) *Runner {
+	if cfg.ShellCommand == "" {
+		cfg.ShellCommand = DefaultShellCommand
+	}
+	if cfg.HiveCommand == "" {
+		cfg.HiveCommand = DefaultHiveCommand
+	}
+
+	executors := map[model.CommandType]Executor{
+		model.ShellCommand:   NewCommandExecutor(cfg.ShellCommand),
+		model.HiveSQLCommand: NewCommandExecutor(cfg.HiveCommand),
+	}
+	if cfg.DMCommand != "" {
+		executors[model.DMProcCommand] = NewCommandExecutor(cfg.DMCommand)
+	}
+	return &Runner{Executors: executors, HdfsSafety: generator.DefaultHdfsSafety()}
+}
+
+func (r *Runner) logf(format string, args ...interface{}) {
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+	if r.Out != nil {
+		fmt.Fprintf(r.Out, format, args...)
+	}
+}
+
+// WithRunID 返回加入了内置参数 ${run_id} 的参数副本。
+func WithRunID(values map[string]string, runID string) map[string]string {
+	merged := make(map[string]string, len(values)+1)
+	for k, v := range values {
+		merged[k] = v
+	}
+	merged[params.RunIDParam] = runID
+	return merged
+}
+
+// plannedStep 是渲染完成、等待执行的步骤。level 为步骤在依赖图中的层次，同层步骤互不依赖。
+type plannedStep struct {
+	step   model.Step
+	script string
+	hash   string
+	level  int
+}
+
+// plan 按拓扑序渲染流程中加载类型为 load 的所有步骤。
+func (r *Runner) plan(process *model.DemoProcess, load model.LoadType, values map[string]string) ([]plannedStep, error) {
+	levels, err := process.Levels(load)
+	if err != nil {
+		return nil, err
+	}
+
+	var planned []plannedStep
+	for level, steps := range levels {
+		for _, step := range steps {
+			script, err := params.Render(step.Content, values)
+			if err != nil {
+				return nil, fmt.Errorf("步骤 %d: %w", step.ID, err)
+			}
+			planned = append(planned, plannedStep{step: step, script: script, hash: HashScript(script), level: level})
+		}
+	}
+	if len(planned) == 0 {
+		return nil, fmt.Errorf("流程 %s 没有加载类型为 %s 的步骤", process.Name, load)
+	}
+	return planned, nil
+}
+
+// Run 开始一次新的运行：按依赖关系执行流程中加载类型为 load 的步骤，脚本中的占位符使用 values
+// 以及内置参数 ${run_id}（取值为 runID）替换。
+// 遇到第一个失败的步骤即停止，返回已执行步骤的结果以及描述失败原因的错误。
+// 配置了 State 时，每个步骤的状态都会写入 runID 对应的状态文件，之前的记录会被覆盖。
+func (r *Runner) Run(ctx context.Context, runID string, process *model.DemoProcess, load model.LoadType, values map[string]string) ([]Result, error) {
+	values = WithRunID(values, runID)
+	planned, err := r.plan(process, load, values)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &RunState{RunID: runID, Process: process.Name, Load: load, Params: values, CreatedAt: time.Now()}
+	for _, p := range planned {
+		state.Steps = append(state.Steps, StepState{ID: p.step.ID, Name: p.step.Name, Status: StatusPending, ScriptHash: p.hash})
+	}
+	return r.executeWithHandlers(ctx, process, state, planned, 0)
+}
+
+// Resume 从上次运行中第一个未成功的步骤继续执行。
+// 如果待执行步骤渲染出的脚本与上次记录的不一致，则拒绝续跑，以免在改动过的脚本上接着跑。
+func (r *Runner) Resume(ctx context.Context, runID string, process *model.DemoProcess) ([]Result, error) {
+	state, planned, err := r.reload(runID, process)
+	if err != nil {
+		return nil, err
+	}
+	start := state.FirstUnsuccessful()
+	if start < 0 {
+		return nil, fmt.Errorf("运行 %s 的所有步骤都已成功，无需续跑", runID)
+	}
+	for i := start; i < len(planned); i++ {
+		if state.Steps[i].ScriptHash != planned[i].hash {
+			return nil, fmt.Errorf("步骤 %d 的脚本自上次运行后已改变，拒绝续跑；如确认无误请使用 rerun -from %d",
+				planned[i].step.ID, planned[start].step.ID)
+		}
+	}
+	return r.executeWithHandlers(ctx, process, state, planned, start)
+}
+
+// Rerun 从指定步骤开始重新执行，该步骤及其后的步骤都会被重置为 pending 并使用最新渲染的脚本。
+func (r *Runner) Rerun(ctx context.Context, runID string, process *model.DemoProcess, fromStep int) ([]Result, error) {
+	state, planned, err := r.reload(runID, process)
+	if err != nil {
+		return nil, err
+	}
+	start := state.indexOf(fromStep)
+	if start < 0 {
+		return nil, fmt.Errorf("运行 %s 中没有步骤 %d", runID, fromStep)
+	}
+	for i := start; i < len(planned); i++ {
+		state.Steps[i] = StepState{ID: planned[i].step.ID, Name: planned[i].step.Name, Status: StatusPending, ScriptHash: planned[i].hash}
+	}
+	return r.executeWithHandlers(ctx, process, state, planned, start)
+}
+
+// reload 读取已有的运行状态，并使用当时的参数重新渲染流程。
+func (r *Runner) reload(runID string, process *model.DemoProcess) (*RunState, []plannedStep, error) {
+	if r.State == nil {
+		return nil, nil, fmt.Errorf("没有配置状态目录，无法续跑")
+	}
+	state, err := r.State.Load(runID)
+	if err != nil {
+		return nil, nil, err
+	}
+	planned, err := r.plan(process, state.Load, state.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(planned) != len(state.Steps) {
+		return nil, nil, fmt.Errorf("流程 %s 的步骤与运行 %s 的记录不一致", process.Name, runID)
+	}
+	for i, p := range planned {
+		if p.step.ID != state.Steps[i].ID {
+			return nil, nil, fmt.Errorf("流程 %s 的步骤与运行 %s 的记录不一致", process.Name, runID)
+		}
+	}
+	return state, planned, nil
+}
+
+func (r *Runner) saveState(state *RunState) error {
+	if r.State == nil || r.DryRun {
+		return nil
+	}
+	return r.State.Save(state)
+}
+
+// lockTables 锁定流程步骤写入的表（DWS 表、达梦中间表和 APP 表，见 Step.DataObjects），
+// 没有识别出写入的表时按流程名加锁。HDFS 中间目录已按运行隔离，不加锁。
+// 锁文件位于状态目录下的 locks 子目录，没有配置状态目录或 dry-run 时不加锁。
+func (r *Runner) lockTables(process *model.DemoProcess, runID string) (*TableLock, error) {
+	if r.State == nil || r.DryRun {
+		return &TableLock{}, nil
+	}
+	tables := writtenTables(process)
+	if len(tables) == 0 {
+		tables = []string{process.Name}
+	}
+	return AcquireTableLocks(filepath.Join(r.State.Dir, "locks"), runID, tables)
+}
+
+// writtenTables 返回流程步骤写入的表（去重，已排序）。
+func writtenTables(process *model.DemoProcess) []string {
+	seen := map[string]struct{}{}
+	var tables []string
+	for _, s := range process.Steps {
+		for _, o := range s.DataObjects() {
+			if o.Access != model.WriteAccess || o.Kind == model.StagingDirObject {
+				continue
+			}
+			if _, ok := seen[o.Name]; !ok {
+				seen[o.Name] = struct{}{}
+				tables = append(tables, o.Name)
+			}
+		}
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// executeWithHandlers 在目标表锁的保护下执行步骤，并在失败时运行流程中同一加载类型的失败处理步骤。
+func (r *Runner) executeWithHandlers(ctx context.Context, process *model.DemoProcess, state *RunState, planned []plannedStep, start int) ([]Result, error) {
+	lock, err := r.lockTables(process, state.RunID)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	results, err := r.execute(ctx, state, planned, start)
+	if err != nil && !r.DryRun {
+		r.runFailureHandlers(process, state)
+	}
+	return results, err
+}
+
+// runFailureHandlers 依次执行失败处理步骤并记录到运行状态中。
+// 失败处理步骤之间互不影响，单个步骤失败只记录不中断；即使运行是被取消的也会执行清理。
+// 每个步骤只执行一次、不重试，全部步骤共用 FailureHandlerTimeout 的时限，Abort 关闭时立即停止。
+func (r *Runner) runFailureHandlers(process *model.DemoProcess, state *RunState) {
+	handlers := process.FailureHandlers(state.Load)
+	if len(handlers) == 0 {
+		return
+	}
+	timeout := r.FailureHandlerTimeout
+	if timeout <= 0 {
+		timeout = DefaultFailureHandlerTimeout
+	}
+	r.logf("[on-failure] 执行 %d 个失败处理步骤，时限 %s\n", len(handlers), timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if r.Abort != nil {
+		go func() {
+			select {
+			case <-r.Abort:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	state.FailureHandlers = nil
+	for _, h := range handlers {
+		hs := StepState{ID: h.ID, Name: h.Name, Status: StatusRunning}
+		started := time.Now()
+		hs.StartedAt = &started
+
+		h.Policy = &model.RetryPolicy{Timeout: timeout}
+		script, err := params.Render(h.Content, state.Params)
+		var result Result
+		if err == nil && ctx.Err() != nil {
+			err = fmt.Errorf("未执行: %w", ctx.Err())
+		}
+		if err == nil {
+			hs.ScriptHash = HashScript(script)
+			result, err = r.runStep(ctx, h, script)
+		}
+		if err == nil && !result.Succeeded() {
+			err = fmt.Errorf("退出码 %d: %v", result.ExitCode, result.Err)
+		}
+
+		finished := time.Now()
+		hs.FinishedAt = &finished
+		hs.ExitCode = result.ExitCode
+		hs.Attempts = result.Attempts
+		if err != nil {
+			hs.Status = StatusFailed
+			hs.Error = err.Error()
+			r.logf("[on-failure] 步骤 %d（%s）失败: %v\n", h.ID, h.Name, err)
+		} else {
+			hs.Status = StatusSucceeded
+		}
+		state.FailureHandlers = append(state.FailureHandlers, hs)
+		if err := r.saveState(state); err != nil {
+			r.logf("[on-failure] 保存状态失败: %v\n", err)
+		}
+	}
+}
+
+// execute 从下标 start 开始按层执行步骤：同一层内尚未成功的步骤最多 MaxParallel 个并发执行，
+// 一层全部结束后才进入下一层，任何步骤失败都会在当前层结束后停止。
+// 每个步骤开始和结束时都会保存状态。
+func (r *Runner) execute(ctx context.Context, state *RunState, planned []plannedStep, start int) ([]Result, error) {
+	var results []Result
+	for i := start; i < len(planned); {
+		// 收集当前层中需要执行的步骤。
+		level := planned[i].level
+		var batch []int
+		for ; i < len(planned) && planned[i].level == level; i++ {
+			if state.Steps[i].Status != StatusSucceeded {
+				batch = append(batch, i)
+			}
+		}
+
+		levelResults, err := r.executeLevel(ctx, state, planned, batch)
+		results = append(results, levelResults...)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// executeLevel 并发执行同一层的步骤，返回按步骤顺序排列的结果以及第一个失败步骤的错误。
+func (r *Runner) executeLevel(ctx context.Context, state *RunState, planned []plannedStep, batch []int) ([]Result, error) {
+	parallel := r.MaxParallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, parallel)
+		results = make([]*Result, len(batch))
+		errs    = make([]error, len(batch))
+	)
+	update := func(fn func()) error {
+		mu.Lock()
+		defer mu.Unlock()
+		fn()
+		return r.saveState(state)
+	}
+
+	// 在启动 goroutine 之前占用并发名额，步骤按 batch 的顺序（层内按步骤 ID）开始执行。
+	for n, idx := range batch {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(n, idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			p := planned[idx]
+			stepState := &state.Steps[idx]
+			if err := update(func() {
+				now := time.Now()
+				stepState.Status = StatusRunning
+				stepState.StartedAt = &now
+				stepState.FinishedAt = nil
+				stepState.Error = ""
+			}); err != nil {
+				errs[n] = err
+				return
+			}
+
+			result, err := r.runStep(ctx, p.step, p.script)
+			if err == nil && !result.Succeeded() {
+				err = fmt.Errorf("步骤 %d（%s）执行失败，退出码 %d: %v", p.step.ID, p.step.Name, result.ExitCode, result.Err)
+			}
+			saveErr := update(func() {
+				finished := time.Now()
+				stepState.FinishedAt = &finished
+				stepState.ExitCode = result.ExitCode
+				stepState.Attempts = result.Attempts
+				if err != nil {
+					stepState.Status = StatusFailed
+					stepState.Error = err.Error()
+				} else {
+					stepState.Status = StatusSucceeded
+				}
+			})
+			if result.StepID != 0 {
+				results[n] = &result
+			}
+			if err == nil {
+				err = saveErr
+			}
+			errs[n] = err
+		}(n, idx)
+	}
+	wg.Wait()
+
+	var ordered []Result
+	for _, res := range results {
+		if res != nil {
+			ordered = append(ordered, *res)
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return ordered, err
+		}
+	}
+	return ordered, nil
+}
+
+// runStep 执行单个已渲染的步骤，失败时按步骤的 RetryPolicy 重试，每次执行都受超时限制；
+// ctx 被取消时立即停止，不再重试。返回的错误表示步骤无法开始执行（例如缺少执行器，
+// 或 shell 步骤中的 HDFS 命令违反 HdfsSafety），
+// 执行本身的失败记录在 Result 中。
+func (r *Runner) runStep(ctx context.Context, step model.Step, script string) (Result, error) {
+	commandType := step.ResolvedCommandType()
+	executor, ok := r.Executors[commandType]
+	if commandType == model.ShellCommand && r.HdfsSafety != nil {
+		if err := generator.CheckHdfsCommands(script, r.HdfsSafety); err != nil {
+			return Result{}, fmt.Errorf("步骤 %d: %w", step.ID, err)
+		}
+	}
+	if r.DryRun {
+		command := "<未配置执行器>"
+		if ok {
+			command = executor.Describe(script)
+		}
+		r.logf("[dry-run] %d. %s（%s） %s: %s\n%s\n\n", step.ID, step.Name, step.Load, commandType, command, script)
+		return Result{StepID: step.ID, Name: step.Name, CommandType: commandType, Command: command}, nil
+	}
+	if !ok {
+		return Result{}, fmt.Errorf("步骤 %d: 没有为命令类型 %s 配置执行器", step.ID, commandType)
+	}
+
+	policy := step.EffectivePolicy()
+	backoff := policy.Backoff
+	var result Result
+	for attempt := 1; ; attempt++ {
+		r.logf("[run] %d. %s（%s） %s: %s（第 %d/%d 次）\n", step.ID, step.Name, step.Load, commandType, executor.Describe(script), attempt, policy.Retries+1)
+		result = r.attempt(ctx, executor, script, policy.Timeout)
+		result.StepID = step.ID
+		result.Name = step.Name
+		result.CommandType = commandType
+		result.Attempts = attempt
+		r.logf("[done] %d. 退出码 %d，耗时 %s\n", step.ID, result.ExitCode, result.Duration)
+
+		if result.Succeeded() || attempt > policy.Retries || ctx.Err() != nil {
+			return result, nil
+		}
+
+		r.logf("[retry] %d. %s 后重试: %v\n", step.ID, backoff, result.Err)
+		select {
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			return result, nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// attempt 在超时限制下执行一次脚本，timeout 为 0 表示不限时。
+func (r *Runner) attempt(ctx context.Context, executor Executor, script string, timeout time.Duration) Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return executor.Execute(ctx, script)
+}
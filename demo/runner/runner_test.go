@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"demo/model"
+)
+
+// recordingExecutor 记录脚本的执行顺序和同时执行的最大数量。
+type recordingExecutor struct {
+	mu      sync.Mutex
+	order   []string
+	running int
+	peak    int
+}
+
+func (e *recordingExecutor) Describe(script string) string { return script }
+
+func (e *recordingExecutor) Execute(ctx context.Context, script string) Result {
+	e.mu.Lock()
+	e.order = append(e.order, script)
+	e.running++
+	if e.running > e.peak {
+		e.peak = e.running
+	}
+	e.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+	return Result{}
+}
+
+// fanOutProcess 返回步骤 1 之后有 n 个互不依赖的步骤（2..n+1）的流程，它们位于同一层。
+func fanOutProcess(n int) *model.DemoProcess {
+	p := &model.DemoProcess{Name: "fan_out"}
+	p.Steps = append(p.Steps, model.Step{ID: 1, Name: "step1", Load: model.IncrementalLoad, CommandType: model.ShellCommand, Content: "1"})
+	for id := 2; id <= n+1; id++ {
+		p.Steps = append(p.Steps, model.Step{
+			ID: id, Name: "step", Load: model.IncrementalLoad, CommandType: model.ShellCommand,
+			DependsOn: []int{1}, Content: string(rune('0' + id)),
+		})
+	}
+	return p
+}
+
+func TestExecuteLevelSerialOrder(t *testing.T) {
+	want := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	for i := 0; i < 20; i++ {
+		exec := &recordingExecutor{}
+		r := &Runner{Executors: map[model.CommandType]Executor{model.ShellCommand: exec}, MaxParallel: 1}
+		results, err := r.Run(context.Background(), "test", fanOutProcess(8), model.IncrementalLoad, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != len(want) {
+			t.Fatalf("got %d results, want %d", len(results), len(want))
+		}
+		for k := range want {
+			if exec.order[k] != want[k] {
+				t.Fatalf("run %d: execution order %v, want %v", i, exec.order, want)
+			}
+			if results[k].StepID != k+1 {
+				t.Fatalf("run %d: result %d is step %d, want %d", i, k, results[k].StepID, k+1)
+			}
+		}
+	}
+}
+
+func TestExecuteLevelMaxParallel(t *testing.T) {
+	exec := &recordingExecutor{}
+	r := &Runner{Executors: map[model.CommandType]Executor{model.ShellCommand: exec}, MaxParallel: 3}
+	if _, err := r.Run(context.Background(), "test", fanOutProcess(8), model.IncrementalLoad, nil); err != nil {
+		t.Fatal(err)
+	}
+	if exec.peak > 3 {
+		t.Errorf("%d steps ran concurrently, want at most 3", exec.peak)
+	}
+	if len(exec.order) != 9 {
+		t.Errorf("%d steps ran, want 9", len(exec.order))
+	}
+}
+
+// failingExecutor 让每个脚本都失败并记录执行次数；脚本为 "block" 时一直等到被取消。
+type failingExecutor struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (e *failingExecutor) Describe(script string) string { return script }
+
+func (e *failingExecutor) Execute(ctx context.Context, script string) Result {
+	e.mu.Lock()
+	if e.calls == nil {
+		e.calls = map[string]int{}
+	}
+	e.calls[script]++
+	e.mu.Unlock()
+	if script == "block" {
+		<-ctx.Done()
+		return Result{ExitCode: -1, Err: ctx.Err()}
+	}
+	return Result{ExitCode: 1}
+}
+
+// failingProcess 返回只有一个步骤、失败后执行 handlers 中脚本的流程，失败处理步骤声明了重试。
+func failingProcess(handlers ...string) *model.DemoProcess {
+	p := &model.DemoProcess{Name: "failing"}
+	p.Steps = []model.Step{{ID: 1, Name: "step1", Load: model.IncrementalLoad, CommandType: model.ShellCommand,
+		Content: "fail", Policy: &model.RetryPolicy{}}}
+	for i, script := range handlers {
+		p.OnFailure = append(p.OnFailure, model.Step{
+			ID: 100 + i, Name: "cleanup", Load: model.IncrementalLoad, CommandType: model.ShellCommand,
+			Content: script, Policy: &model.RetryPolicy{Retries: 3, Backoff: time.Minute},
+		})
+	}
+	return p
+}
+
+func TestFailureHandlersRunOnce(t *testing.T) {
+	exec := &failingExecutor{}
+	r := &Runner{Executors: map[model.CommandType]Executor{model.ShellCommand: exec}}
+	if _, err := r.Run(context.Background(), "test", failingProcess("cleanup1", "cleanup2"), model.IncrementalLoad, nil); err == nil {
+		t.Fatal("Run() succeeded, want an error")
+	}
+	for _, script := range []string{"cleanup1", "cleanup2"} {
+		if n := exec.calls[script]; n != 1 {
+			t.Errorf("%s ran %d times, want 1", script, n)
+		}
+	}
+}
+
+func TestFailureHandlersAbort(t *testing.T) {
+	exec := &failingExecutor{}
+	abort := make(chan struct{})
+	r := &Runner{Executors: map[model.CommandType]Executor{model.ShellCommand: exec}, Abort: abort}
+	time.AfterFunc(10*time.Millisecond, func() { close(abort) })
+
+	started := time.Now()
+	r.Run(context.Background(), "test", failingProcess("block", "cleanup"), model.IncrementalLoad, nil)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("failure handlers took %s after abort", elapsed)
+	}
+	if n := exec.calls["cleanup"]; n != 0 {
+		t.Errorf("cleanup ran %d times after abort, want 0", n)
+	}
+}
+
+func TestFailureHandlersTimeout(t *testing.T) {
+	exec := &failingExecutor{}
+	r := &Runner{Executors: map[model.CommandType]Executor{model.ShellCommand: exec}, FailureHandlerTimeout: 10 * time.Millisecond}
+
+	started := time.Now()
+	r.Run(context.Background(), "test", failingProcess("block"), model.IncrementalLoad, nil)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("failure handlers took %s, want the 10ms timeout", elapsed)
+	}
+}
+
+func TestLockTablesWrittenObjects(t *testing.T) {
+	p := &model.DemoProcess{Name: "demo", Steps: []model.Step{
+		{ID: 1, Load: model.IncrementalLoad, CommandType: model.HiveSQLCommand,
+			Content: "insert overwrite table dws.T_DWS_X partition(dt='${mt1}') select a from dwd.T_SRC s"},
+		{ID: 2, Load: model.IncrementalLoad, CommandType: model.HiveSQLCommand,
+			Content: "insert overwrite directory '/tmp/hive/hive/T_DWS_X' select a from dws.T_DWS_X"},
+		{ID: 3, Load: model.IncrementalLoad, CommandType: model.DMProcCommand, Content: "p_create_mid_app('T_APP_X', null);"},
+		{ID: 4, Load: model.IncrementalLoad, CommandType: model.ShellCommand,
+			Content: "sqoop export --table MID_T_APP_X --export-dir /tmp/hive/hive/T_DWS_X"},
+		{ID: 5, Load: model.IncrementalLoad, CommandType: model.DMProcCommand,
+			Content: "p_replace_tgttable('T_APP_X', 'DI', 'DATA_MONTH', '${mt1}', 1, null);"},
+	}}
+	dir := t.TempDir()
+	r := &Runner{State: &StateStore{Dir: dir}}
+	lock, err := r.lockTables(p, "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+
+	want := []string{"MID_T_APP_X", "T_APP_X", "T_DWS_X"}
+	if got := writtenTables(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("writtenTables() = %v, want %v", got, want)
+	}
+	// 另一个运行不能同时写入同一张 APP 表。
+	other := &model.DemoProcess{Name: "other", Steps: p.Steps[4:]}
+	if _, err := r.lockTables(other, "run2"); err == nil {
+		t.Error("lockTables() of a process writing a locked APP table succeeded")
+	}
+}
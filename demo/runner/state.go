@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"demo/model"
+)
+
+// StepStatus 表示步骤在一次运行中的状态。
+type StepStatus string
+
+const (
+	StatusPending   StepStatus = "pending"
+	StatusRunning   StepStatus = "running"
+	StatusSucceeded StepStatus = "succeeded"
+	StatusFailed    StepStatus = "failed"
+)
+
+// StepState 记录一次运行中单个步骤的状态，以及执行时渲染出的脚本摘要。
+type StepState struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Status     StepStatus `json:"status"`
+	ScriptHash string     `json:"scriptHash"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	ExitCode   int        `json:"exitCode"`
+	Attempts   int        `json:"attempts,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// RunState 是持久化到状态文件中的一次运行记录。
+type RunState struct {
+	RunID     string            `json:"runId"`
+	Process   string            `json:"process"`
+	Load      model.LoadType    `json:"load"`
+	Params    map[string]string `json:"params"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	Steps     []StepState       `json:"steps"`
+	// FailureHandlers 记录最近一次失败后执行的失败处理步骤。
+	FailureHandlers []StepState `json:"failureHandlers,omitempty"`
+}
+
+// FirstUnsuccessful 返回第一个未成功步骤的下标，全部成功时返回 -1。
+func (s *RunState) FirstUnsuccessful() int {
+	for i, step := range s.Steps {
+		if step.Status != StatusSucceeded {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOf 返回步骤 ID 在运行记录中的下标，不存在时返回 -1。
+func (s *RunState) indexOf(stepID int) int {
+	for i, step := range s.Steps {
+		if step.ID == stepID {
+			return i
+		}
+	}
+	return -1
+}
+
+// StateStore 将运行状态以 JSON 文件的形式保存在本地目录中，每次运行一个文件。
+type StateStore struct {
+	Dir string
+}
+
+func (s *StateStore) path(runID string) string {
+	return filepath.Join(s.Dir, runID+".json")
+}
+
+// Load 读取指定运行的状态文件。
+func (s *StateStore) Load(runID string) (*RunState, error) {
+	data, err := os.ReadFile(s.path(runID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("运行 %s 没有状态记录", runID)
+		}
+		return nil, err
+	}
+	var state RunState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("状态文件 %s 已损坏: %w", s.path(runID), err)
+	}
+	return &state, nil
+}
+
+// Save 写入运行状态。先写临时文件再重命名，避免进程中断时留下半个文件。
+func (s *StateStore) Save(state *RunState) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	state.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path(state.RunID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(state.RunID))
+}
+
+var reUnsafeRunID = regexp.MustCompile(`[^\w.-]+`)
+
+// RunID 根据流程名、加载类型和参数值生成运行 ID，例如 T_DWS_XXX_incr_mt1-202409。
+// 同一流程、同一周期的重复执行会得到相同的 ID，从而可以续跑。
+func RunID(process string, load model.LoadType, values map[string]string) string {
+	parts := []string{process}
+	if load != "" {
+		parts = append(parts, load.Code())
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name+"-"+values[name])
+	}
+	return reUnsafeRunID.ReplaceAllString(strings.Join(parts, "_"), "_")
+}
+
+// HashScript 返回渲染后脚本的 SHA-256 摘要。
+func HashScript(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,390 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"demo/model"
+	"demo/params"
+)
+
+// 以下结构对应 DolphinScheduler 工作流导出文件（process-definition/import 接口接受的 JSON）中
+// 本项目用到的字段，其余字段由 DolphinScheduler 在导入时补全默认值。
+
+// ProcessDefinition 描述一个工作流。
+type ProcessDefinition struct {
+	Name          string `json:"name"`
+	Code          int64  `json:"code"`
+	ProjectCode   int64  `json:"projectCode"`
+	Description   string `json:"description"`
+	GlobalParams  string `json:"globalParams"`
+	ExecutionType string `json:"executionType"`
+	Timeout       int    `json:"timeout"`
+	ReleaseState  string `json:"releaseState"`
+}
+
+// TaskDefinition 描述工作流中的一个任务节点。
+type TaskDefinition struct {
+	Code                  int64                  `json:"code"`
+	Name                  string                 `json:"name"`
+	Description           string                 `json:"description"`
+	ProjectCode           int64                  `json:"projectCode"`
+	TaskType              string                 `json:"taskType"`
+	TaskParams            map[string]interface{} `json:"taskParams"`
+	Flag                  string                 `json:"flag"`
+	TaskPriority          string                 `json:"taskPriority"`
+	WorkerGroup           string                 `json:"workerGroup"`
+	FailRetryTimes        int                    `json:"failRetryTimes"`
+	FailRetryInterval     int                    `json:"failRetryInterval"`
+	TimeoutFlag           string                 `json:"timeoutFlag"`
+	TimeoutNotifyStrategy string                 `json:"timeoutNotifyStrategy,omitempty"`
+	Timeout               int                    `json:"timeout"`
+}
+
+// TaskRelation 描述任务之间的依赖边，PreTaskCode 为 0 表示没有前置任务。
+type TaskRelation struct {
+	PreTaskCode     int64                  `json:"preTaskCode"`
+	PostTaskCode    int64                  `json:"postTaskCode"`
+	ConditionType   string                 `json:"conditionType"`
+	ConditionParams map[string]interface{} `json:"conditionParams"`
+}
+
+// Workflow 是单个工作流的导出内容。
+type Workflow struct {
+	ProcessDefinition       ProcessDefinition `json:"processDefinition"`
+	ProcessTaskRelationList []TaskRelation    `json:"processTaskRelationList"`
+	TaskDefinitionList      []TaskDefinition  `json:"taskDefinitionList"`
+}
+
+// Options 描述导出时需要的 DolphinScheduler 环境信息。
+type Options struct {
+	ProjectCode int64
+	// HiveDatasource 与 DMDatasource 为 DolphinScheduler 中已配置的数据源 ID。
+	HiveDatasource int
+	DMDatasource   int
+	// Datasources 将步骤中声明的数据源名称（Step.Datasource）映射为数据源 ID。
+	Datasources map[string]int
+	WorkerGroup string
+	// Load 为要导出的加载类型，为空时导出增量步骤。
+	Load model.LoadType
+}
+
+// Code 根据名称生成稳定的节点编码，使重复导出同一流程得到相同的 code，便于覆盖导入。
+func Code(parts ...string) int64 {
+	h := fnv.New64a()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	// DolphinScheduler 的 code 为正的 long，且前端按 JavaScript 数字处理，因此限制在 53 位以内。
+	return int64(h.Sum64() & (1<<53 - 1))
+}
+
+// globalParams 将流程的运行参数转换为 DolphinScheduler 的内置时间参数，例如 mt1 => $[add_months(yyyyMM,-1)]。
+func globalParams(decls []model.RunParam) (string, error) {
+	type property struct {
+		Prop   string `json:"prop"`
+		Direct string `json:"direct"`
+		Type   string `json:"type"`
+		Value  string `json:"value"`
+	}
+	props := []property{}
+	for _, d := range decls {
+		var value string
+		switch d.Granularity {
+		case model.MonthGranularity:
+			value = fmt.Sprintf("$[add_months(yyyyMM,%d)]", d.Offset)
+		case model.DayGranularity:
+			value = fmt.Sprintf("$[yyyyMMdd%+d]", d.Offset)
+		default:
+			return "", fmt.Errorf("参数 %s 的粒度 %q 无法导出", d.Name, d.Granularity)
+		}
+		props = append(props, property{Prop: d.Name, Direct: "IN", Type: "VARCHAR", Value: value})
+	}
+	// 中间目录按运行 ID 区分，由调度系统的工作流实例 ID 提供。
+	props = append(props, property{Prop: params.RunIDParam, Direct: "IN", Type: "VARCHAR", Value: "${system.workflow.instance.id}"})
+	data, err := json.Marshal(props)
+	return string(data), err
+}
+
+// taskParams 根据步骤的命令类型生成任务参数，步骤声明了数据源时使用 Options.Datasources 中对应的 ID。
+func taskParams(step model.Step, opts Options) (string, map[string]interface{}, error) {
+	datasource := func(fallback int) (int, error) {
+		if step.Datasource == "" {
+			return fallback, nil
+		}
+		id, ok := opts.Datasources[step.Datasource]
+		if !ok {
+			return 0, fmt.Errorf("步骤 %d 的数据源 %s 没有配置对应的数据源 ID", step.ID, step.Datasource)
+		}
+		return id, nil
+	}
+
+	switch step.ResolvedCommandType() {
+	case model.HiveSQLCommand:
+		id, err := datasource(opts.HiveDatasource)
+		if err != nil {
+			return "", nil, err
+		}
+		return "SQL", map[string]interface{}{
+			"type":        "HIVE",
+			"datasource":  id,
+			"sql":         step.Content,
+			"sqlType":     "1",
+			"localParams": []interface{}{},
+		}, nil
+	case model.DMProcCommand:
+		sql := step.Content
+		// 存储过程调用需要加上 call，失败处理中的 DROP/TRUNCATE 等普通 SQL 原样执行。
+		if model.InferCommandType(sql) == model.DMProcCommand {
+			sql = "call " + sql
+		}
+		id, err := datasource(opts.DMDatasource)
+		if err != nil {
+			return "", nil, err
+		}
+		return "SQL", map[string]interface{}{
+			"type":        "DAMENG",
+			"datasource":  id,
+			"sql":         sql,
+			"sqlType":     "1",
+			"localParams": []interface{}{},
+		}, nil
+	default:
+		return "SHELL", map[string]interface{}{
+			"rawScript":    step.Content,
+			"localParams":  []interface{}{},
+			"resourceList": []interface{}{},
+		}, nil
+	}
+}
+
+// newTask 创建带有公共默认值的任务节点。
+func newTask(process, name, taskType string, taskParams map[string]interface{}, opts Options) TaskDefinition {
+	workerGroup := opts.WorkerGroup
+	if workerGroup == "" {
+		workerGroup = "default"
+	}
+	return TaskDefinition{
+		Code:              Code(process, name),
+		Name:              name,
+		ProjectCode:       opts.ProjectCode,
+		TaskType:          taskType,
+		TaskParams:        taskParams,
+		Flag:              "YES",
+		TaskPriority:      "MEDIUM",
+		WorkerGroup:       workerGroup,
+		FailRetryInterval: 1,
+		TimeoutFlag:       "CLOSE",
+	}
+}
+
+// minutesCeil 将时长换算为分钟并向上取整，DolphinScheduler 的重试间隔和超时都以分钟为单位。
+func minutesCeil(d time.Duration) int {
+	return int((d + time.Minute - 1) / time.Minute)
+}
+
+// applyPolicy 将步骤的重试与超时策略写入任务定义，超时后任务直接失败并进入重试。
+func applyPolicy(task *TaskDefinition, policy model.RetryPolicy) {
+	task.FailRetryTimes = policy.Retries
+	if interval := minutesCeil(policy.Backoff); interval > 0 {
+		task.FailRetryInterval = interval
+	}
+	if policy.Timeout > 0 {
+		task.TimeoutFlag = "OPEN"
+		task.TimeoutNotifyStrategy = "FAILED"
+		task.Timeout = minutesCeil(policy.Timeout)
+	}
+}
+
+// dependentTask 创建等待上游工作流当天实例成功的 DEPENDENT 任务。
+func dependentTask(process string, upstream []string, opts Options) TaskDefinition {
+	var items []map[string]interface{}
+	for _, name := range upstream {
+		items = append(items, map[string]interface{}{
+			"projectCode":    opts.ProjectCode,
+			"definitionCode": Code(name),
+			"depTaskCode":    0,
+			"cycle":          "day",
+			"dateValue":      "today",
+		})
+	}
+	dependence := map[string]interface{}{
+		"dependence": map[string]interface{}{
+			"relation": "AND",
+			"dependTaskList": []map[string]interface{}{
+				{"relation": "AND", "dependItemList": items},
+			},
+		},
+	}
+	task := newTask(process, "wait_upstream", "DEPENDENT", dependence, opts)
+	task.Description = "等待上游工作流完成"
+	return task
+}
+
+func relation(pre, post int64) TaskRelation {
+	return TaskRelation{PreTaskCode: pre, PostTaskCode: post, ConditionType: "NONE", ConditionParams: map[string]interface{}{}}
+}
+
+// ExportProcess 将流程中指定加载类型的步骤导出为一个 DolphinScheduler 工作流。
+// 步骤之间的依赖来自 DemoProcess.Dependencies；upstream 非空时额外生成一个 DEPENDENT 任务，
+// 所有没有前置步骤的任务都要等待它完成，从而保证上游工作流先于本工作流运行。
+func ExportProcess(process *model.DemoProcess, upstream []string, opts Options) (*Workflow, error) {
+	load := opts.Load
+	if load == "" {
+		load = model.IncrementalLoad
+	}
+	levels, err := process.Levels(load)
+	if err != nil {
+		return nil, err
+	}
+	globals, err := globalParams(params.Declared(process))
+	if err != nil {
+		return nil, err
+	}
+
+	wf := &Workflow{
+		ProcessDefinition: ProcessDefinition{
+			Name:          process.Name,
+			Code:          Code(process.Name),
+			ProjectCode:   opts.ProjectCode,
+			Description:   fmt.Sprintf("%s（%s）", process.Name, load),
+			GlobalParams:  globals,
+			ExecutionType: "PARALLEL",
+			ReleaseState:  "OFFLINE",
+		},
+	}
+
+	var rootCode int64
+	if len(upstream) > 0 {
+		wait := dependentTask(process.Name, upstream, opts)
+		wf.TaskDefinitionList = append(wf.TaskDefinitionList, wait)
+		wf.ProcessTaskRelationList = append(wf.ProcessTaskRelationList, relation(0, wait.Code))
+		rootCode = wait.Code
+	}
+
+	handlers := process.FailureHandlers(load)
+	var handlerCodes []int64
+	for _, h := range handlers {
+		handlerCodes = append(handlerCodes, Code(process.Name, taskName(h)))
+	}
+
+	deps := process.Dependencies()
+	dependents := make(map[int][]int64)
+	for _, level := range levels {
+		for _, step := range level {
+			for _, dep := range deps[step.ID] {
+				dependents[dep] = append(dependents[dep], Code(process.Name, taskName(step)))
+			}
+		}
+	}
+
+	// upstreamOf 返回下游任务应当连接的上游节点：有失败处理步骤时为该步骤的 CONDITIONS 节点，否则为步骤本身。
+	upstreamOf := func(step model.Step) int64 {
+		if len(handlers) > 0 {
+			return Code(process.Name, conditionName(step))
+		}
+		return Code(process.Name, taskName(step))
+	}
+	byID := make(map[int]model.Step)
+
+	for _, level := range levels {
+		for _, step := range level {
+			byID[step.ID] = step
+			taskType, stepParams, err := taskParams(step, opts)
+			if err != nil {
+				return nil, err
+			}
+			task := newTask(process.Name, taskName(step), taskType, stepParams, opts)
+			task.Description = fmt.Sprintf("%s（%s）", step.Name, step.Load)
+			applyPolicy(&task, step.EffectivePolicy())
+			wf.TaskDefinitionList = append(wf.TaskDefinitionList, task)
+
+			if len(deps[step.ID]) == 0 {
+				wf.ProcessTaskRelationList = append(wf.ProcessTaskRelationList, relation(rootCode, task.Code))
+			}
+			for _, dep := range deps[step.ID] {
+				wf.ProcessTaskRelationList = append(wf.ProcessTaskRelationList, relation(upstreamOf(byID[dep]), task.Code))
+			}
+
+			if len(handlers) > 0 {
+				cond := conditionTask(process.Name, step, task.Code, dependents[step.ID], handlerCodes, opts)
+				wf.TaskDefinitionList = append(wf.TaskDefinitionList, cond)
+				wf.ProcessTaskRelationList = append(wf.ProcessTaskRelationList, relation(task.Code, cond.Code))
+				for _, code := range handlerCodes {
+					wf.ProcessTaskRelationList = append(wf.ProcessTaskRelationList, relation(cond.Code, code))
+				}
+			}
+		}
+	}
+
+	for _, h := range handlers {
+		taskType, stepParams, err := taskParams(h, opts)
+		if err != nil {
+			return nil, err
+		}
+		task := newTask(process.Name, taskName(h), taskType, stepParams, opts)
+		task.Description = fmt.Sprintf("%s（%s，失败处理）", h.Name, h.Load)
+		applyPolicy(&task, h.EffectivePolicy())
+		wf.TaskDefinitionList = append(wf.TaskDefinitionList, task)
+	}
+	return wf, nil
+}
+
+func taskName(step model.Step) string {
+	return fmt.Sprintf("%02d_%s", step.ID, step.Name)
+}
+
+func conditionName(step model.Step) string {
+	return fmt.Sprintf("%02d_检查结果", step.ID)
+}
+
+// conditionTask 创建跟在步骤任务之后的 CONDITIONS 节点：步骤成功时进入下游任务，失败时进入失败处理任务。
+// 失败分支上的下游任务会被 DolphinScheduler 标记为跳过，因此任一步骤失败都会触发清理，
+// 而全部成功时清理任务的所有上游分支都被跳过，清理任务也随之跳过。
+func conditionTask(process string, step model.Step, stepCode int64, successNodes, failedNodes []int64, opts Options) TaskDefinition {
+	if successNodes == nil {
+		successNodes = []int64{}
+	}
+	taskParams := map[string]interface{}{
+		"dependence": map[string]interface{}{
+			"relation": "AND",
+			"dependTaskList": []map[string]interface{}{
+				{
+					"relation": "AND",
+					"dependItemList": []map[string]interface{}{
+						{"depTaskCode": stepCode, "status": "SUCCESS"},
+					},
+				},
+			},
+		},
+		"conditionResult": map[string]interface{}{
+			"successNode": successNodes,
+			"failedNode":  failedNodes,
+		},
+	}
+	task := newTask(process, conditionName(step), "CONDITIONS", taskParams, opts)
+	task.Description = fmt.Sprintf("步骤 %d 失败时执行失败处理", step.ID)
+	return task
+}
+
+// ExportCollection 按构建顺序导出集合中的所有流程，流程之间的依赖导出为 DEPENDENT 任务。
+func ExportCollection(c *model.ProcessCollection, opts Options) ([]Workflow, error) {
+	order, err := c.BuildOrder()
+	if err != nil {
+		return nil, err
+	}
+	deps := c.Dependencies()
+
+	var workflows []Workflow
+	for i := range order {
+		wf, err := ExportProcess(&order[i], deps[order[i].Name], opts)
+		if err != nil {
+			return nil, fmt.Errorf("流程 %s: %w", order[i].Name, err)
+		}
+		workflows = append(workflows, *wf)
+	}
+	return workflows, nil
+}
@@ -0,0 +1,31 @@
+package steps
+
+import "demo/generator"
+
+// dwsTable 是第三、四步导出的 DWS 表。
+const dwsTable = "T_DWS_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR"
+
+// dwsFields 是 DWS 表的输出字段及其类型，HDFS 导出的 SELECT 列由它生成。
+var dwsFields = []generator.ExportField{
+	{Name: "ETL_TIME", Type: "string"},
+	{Name: "SELL_DATE", Type: "string"},
+	{Name: "SELL_WEEK", Type: "string"},
+	{Name: "SELL_YM", Type: "string"},
+	{Name: "SELL_Y", Type: "string"},
+	{Name: "VOYAGE", Type: "string"},
+	{Name: "BUS_DEP", Type: "string"},
+	{Name: "ISS_AIR_NAME", Type: "string"},
+	{Name: "DAF_MARK", Type: "string"},
+	{Name: "DIRDIS_MARK", Type: "string"},
+	{Name: "ABROAD_AIRPORT_AREA", Type: "string"},
+	{Name: "IS_LOCPS_AIRPORT_DEP", Type: "string"},
+	{Name: "IS_LOCPS_AIRPORT", Type: "string"},
+	{Name: "TEAM_MARK", Type: "string"},
+	{Name: "VOYAGE_MARK", Type: "string"},
+	{Name: "CHN_AREA", Type: "string"},
+	{Name: "CHN_NATURE", Type: "string"},
+	{Name: "CHN_DETAIL_1", Type: "string"},
+	{Name: "CHN_DETAIL_2", Type: "string"},
+	{Name: "SALE_AMT", Type: "DECIMAL(22,3)"},
+	{Name: "SALE_NUM", Type: "DECIMAL(22,0)"},
+}
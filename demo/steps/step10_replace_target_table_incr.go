@@ -0,0 +1,15 @@
+package steps
+
+import "demo/generator"
+
+// GetStep10ReplaceTargetTableIncrConfig 创建并返回一个为第十步（替换达梦目标表-增量）
+// 专门配置的 ReplaceTargetTable 对象：以增量（DI）方式替换目标表中 ${mt1} 这一个周期的数据。
+func GetStep10ReplaceTargetTableIncrConfig() *generator.ReplaceTargetTable {
+	return &generator.ReplaceTargetTable{
+		TargetTable:     "T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR",
+		Mode:            generator.IncrementalReplace,
+		PartitionColumn: "DATA_MONTH",
+		PeriodParam:     "mt1",
+		Retention:       1,
+	}
+}
@@ -0,0 +1,12 @@
+package steps
+
+import (
+	"demo/generator"
+	"demo/model"
+)
+
+// GetStep11DeleteHdfsTempInitConfig 创建并返回一个为第十一步（删除hive中间临时文件-初始化）
+// 专门配置的 HdfsCommand 对象，删除第三步导出的中间目录。
+func GetStep11DeleteHdfsTempInitConfig() *generator.HdfsCommand {
+	return generator.NewHdfsRemove(generator.NewStagingPath(dwsTable, model.InitializationLoad, "mt1").Generate())
+}
@@ -0,0 +1,12 @@
+package steps
+
+import (
+	"demo/generator"
+	"demo/model"
+)
+
+// GetStep12DeleteHdfsTempIncrConfig 创建并返回一个为第十二步（删除hive中间临时文件-增量）
+// 专门配置的 HdfsCommand 对象，删除第四步导出的中间目录。
+func GetStep12DeleteHdfsTempIncrConfig() *generator.HdfsCommand {
+	return generator.NewHdfsRemove(generator.NewStagingPath(dwsTable, model.IncrementalLoad, "mt1").Generate())
+}
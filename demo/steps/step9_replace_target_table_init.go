@@ -0,0 +1,13 @@
+package steps
+
+import "demo/generator"
+
+// GetStep9ReplaceTargetTableInitConfig 创建并返回一个为第九步（替换达梦目标表-初始化）
+// 专门配置的 ReplaceTargetTable 对象：以全量（DF）方式用中间表替换目标表。
+func GetStep9ReplaceTargetTableInitConfig() *generator.ReplaceTargetTable {
+	return &generator.ReplaceTargetTable{
+		TargetTable:     "T_APP_INTERNAT_CHN_STRUCT_ANALYSIS_FLYR",
+		Mode:            generator.FullReplace,
+		PartitionColumn: "DATA_MONTH",
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"demo/generator"
+	"demo/lint"
+	"demo/model"
+	"demo/params"
+	"demo/parser"
+)
+
+// Issue is an inconsistency between steps of one process.
+type Issue struct {
+	Load    model.LoadType
+	Steps   []int
+	Message string
+}
+
+func (i Issue) String() string {
+	ids := make([]string, len(i.Steps))
+	for k, id := range i.Steps {
+		ids[k] = fmt.Sprint(id)
+	}
+	return fmt.Sprintf("[%s] steps %s: %s", i.Load, strings.Join(ids, ","), i.Message)
+}
+
+// hiveDefaultTerminator is the field delimiter Hive uses for INSERT OVERWRITE DIRECTORY
+// without a ROW FORMAT clause.
+const hiveDefaultTerminator = `\001`
+
+// sqoopDefaultTerminator is the input field delimiter Sqoop export assumes when
+// --input-fields-terminated-by is not given.
+const sqoopDefaultTerminator = ","
+
+var reCreateMidApp = regexp.MustCompile(`(?i)p_create_mid_app\s*\(\s*'([^']+)'`)
+
+// hdfsExport is a Hive step that writes query results to an HDFS directory.
+type hdfsExport struct {
+	step model.Step
+	model.HdfsExport
+}
+
+// sqoopExport is a shell step running `sqoop export`.
+type sqoopExport struct {
+	step model.Step
+	model.SqoopExport
+}
+
+// parseHdfsExport parses a Hive export step, defaulting to the delimiter Hive uses
+// without a ROW FORMAT clause.
+func parseHdfsExport(s model.Step) (hdfsExport, bool) {
+	e, ok := model.ParseHdfsExport(s.Content)
+	if e.Terminator == "" {
+		e.Terminator = hiveDefaultTerminator
+	}
+	return hdfsExport{step: s, HdfsExport: e}, ok
+}
+
+// parseSqoopExport parses a Sqoop export step, defaulting to the delimiter Sqoop
+// assumes without --input-fields-terminated-by.
+func parseSqoopExport(s model.Step) (sqoopExport, bool) {
+	e, ok := model.ParseSqoopExport(s.Content)
+	if e.Terminator == "" {
+		e.Terminator = sqoopDefaultTerminator
+	}
+	return sqoopExport{step: s, SqoopExport: e}, ok
+}
+
+// normalizeTerminator maps the spellings Hive and Sqoop accept for the same
+// delimiter to one form, e.g. '\u0001' and '\001'.
+func normalizeTerminator(t string) string {
+	switch t {
+	case `\u0001`, `\x01`, "\x01", `\01`, `\1`:
+		return hiveDefaultTerminator
+	case "\t":
+		return `\t`
+	}
+	return t
+}
+
+// appTableOf returns the APP table a MID table is created from (MID_T_APP_X -> T_APP_X).
+func appTableOf(midTable string) string {
+	return strings.TrimPrefix(strings.ToUpper(midTable), "MID_")
+}
+
+// validateProcess checks, per load type, that the steps of a process agree with each
+// other: every Sqoop export reads the directory a Hive step writes with the same field
+// delimiter, loads a MID table created by p_create_mid_app, and exports as many columns
+// as the APP table has. appColumns maps APP table names to their columns; tables
+// missing from it skip the column count check.
+func validateProcess(process *model.DemoProcess, appColumns map[string][]string) []Issue {
+	var issues []Issue
+	for _, load := range []model.LoadType{model.InitializationLoad, model.IncrementalLoad} {
+		var hdfs []hdfsExport
+		var sqoops []sqoopExport
+		midTables := map[string][]int{}
+		var createSteps []int
+		for _, s := range process.Steps {
+			if s.Load != load {
+				continue
+			}
+			if e, ok := parseHdfsExport(s); ok {
+				hdfs = append(hdfs, e)
+			}
+			if e, ok := parseSqoopExport(s); ok {
+				sqoops = append(sqoops, e)
+			}
+			for _, m := range reCreateMidApp.FindAllStringSubmatch(s.Content, -1) {
+				mid := "MID_" + strings.ToUpper(m[1])
+				midTables[mid] = append(midTables[mid], s.ID)
+				createSteps = append(createSteps, s.ID)
+			}
+		}
+
+		for _, sq := range sqoops {
+			issue := func(msg string, others ...int) {
+				ids := append([]int{sq.step.ID}, others...)
+				sort.Ints(ids)
+				issues = append(issues, Issue{Load: load, Steps: ids, Message: msg})
+			}
+
+			var source *hdfsExport
+			var hdfsIDs []int
+			for i := range hdfs {
+				hdfsIDs = append(hdfsIDs, hdfs[i].step.ID)
+				if strings.TrimRight(hdfs[i].Dir, "/") == strings.TrimRight(sq.Dir, "/") {
+					source = &hdfs[i]
+				}
+			}
+			switch {
+			case sq.Dir == "":
+				issue("sqoop export has no --export-dir")
+			case source == nil && len(hdfs) == 0:
+				issue(fmt.Sprintf("--export-dir %s is not written by any Hive step", sq.Dir))
+			case source == nil:
+				var dirs []string
+				for _, h := range hdfs {
+					dirs = append(dirs, h.Dir)
+				}
+				issue(fmt.Sprintf("--export-dir %s does not match the Hive DirectoryPath %s", sq.Dir, strings.Join(dirs, ", ")), hdfsIDs...)
+			case normalizeTerminator(source.Terminator) != normalizeTerminator(sq.Terminator):
+				issue(fmt.Sprintf("Hive writes fields terminated by '%s' but sqoop reads '%s'", source.Terminator, sq.Terminator), source.step.ID)
+			}
+
+			if sq.Table == "" {
+				issue("sqoop export has no --table")
+				continue
+			}
+			if _, ok := midTables[strings.ToUpper(sq.Table)]; !ok {
+				if len(createSteps) == 0 {
+					issue(fmt.Sprintf("--table %s is not created by any p_create_mid_app step", sq.Table))
+				} else {
+					issue(fmt.Sprintf("--table %s does not match the MID table created by p_create_mid_app", sq.Table), createSteps...)
+				}
+			}
+
+			if source == nil {
+				continue
+			}
+			if len(sq.Columns) > 0 && len(sq.Columns) != len(source.Columns) {
+				issue(fmt.Sprintf("export SELECT has %d columns but --columns lists %d", len(source.Columns), len(sq.Columns)), source.step.ID)
+			}
+			app := appTableOf(sq.Table)
+			if cols, ok := appColumns[app]; ok && len(cols) != len(source.Columns) {
+				issue(fmt.Sprintf("export SELECT has %d columns but %s has %d", len(source.Columns), app, len(cols)), source.step.ID)
+			}
+		}
+	}
+	return issues
+}
+
+// readAppColumns reads APP table definitions, one table per line in the form
+// `T_APP_XXX: COL1, COL2, ...`. Blank lines and lines starting with # are ignored.
+func readAppColumns(file string) (map[string][]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tables := map[string][]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, cols, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected `TABLE: COL1, COL2, ...`", file, n)
+		}
+		tables[strings.ToUpper(strings.TrimSpace(name))] = model.SplitTopLevel(cols)
+	}
+	return tables, scanner.Err()
+}
+
+// validatePartitions reports Hive steps that read a table listed in the partition catalog
+// without filtering on its partition column, i.e. that would scan every partition.
+// The suggested filter follows each step's load type and the process's period parameter.
+func validatePartitions(process *model.DemoProcess, catalog generator.PartitionCatalog) []Issue {
+	var issues []Issue
+	periodParam := params.Declared(process)[0].Name
+	for _, s := range process.Steps {
+		if s.ResolvedCommandType() != model.HiveSQLCommand {
+			continue
+		}
+		for _, f := range lint.LintWithCatalog(s.Content, catalog, s.Load, periodParam) {
+			if f.Rule == lint.UnprunedPartition.ID {
+				issues = append(issues, Issue{Load: s.Load, Steps: []int{s.ID}, Message: fmt.Sprintf("line %d: %s", f.Line, f.Message)})
+			}
+		}
+	}
+	return issues
+}
+
+// readCatalog reads a partition catalog file (see generator.ParsePartitionCatalog).
+func readCatalog(file string) (generator.PartitionCatalog, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := generator.ParsePartitionCatalog(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return catalog, nil
+}
+
+// validateFunctions reports Hive steps calling functions that are neither Hive built-ins
+// nor registered custom functions, which Hive would reject as invalid functions.
+func validateFunctions(process *model.DemoProcess, udfs generator.UDFRegistry) []Issue {
+	var issues []Issue
+	for _, s := range process.Steps {
+		if s.ResolvedCommandType() != model.HiveSQLCommand {
+			continue
+		}
+		if unknown := udfs.Unknown(s.Content); len(unknown) > 0 {
+			issues = append(issues, Issue{Load: s.Load, Steps: []int{s.ID}, Message: fmt.Sprintf("unknown functions %s; register them in the UDF registry", strings.Join(unknown, ", "))})
+		}
+	}
+	return issues
+}
+
+// validateProcedures reports calls in dm_proc steps to known stored procedures whose
+// arguments do not match the procedure's signature (see generator.KnownProcedures).
+func validateProcedures(process *model.DemoProcess) []Issue {
+	var issues []Issue
+	for _, s := range process.Steps {
+		if s.ResolvedCommandType() != model.DMProcCommand {
+			continue
+		}
+		for _, call := range generator.ProcedureCalls(s.Content) {
+			if err := call.Validate(); err != nil {
+				issues = append(issues, Issue{Load: s.Load, Steps: []int{s.ID}, Message: err.Error()})
+			}
+		}
+	}
+	return issues
+}
+
+// validateFieldLogic returns a message for each 字段逻辑 in tables.txt that calls a
+// function that is neither a Hive built-in nor a registered custom function.
+func validateFieldLogic(tables []*parser.DwsTable, udfs generator.UDFRegistry) []string {
+	var messages []string
+	for _, t := range tables {
+		for _, f := range t.Fields {
+			if unknown := udfs.Unknown(f.Logic); len(unknown) > 0 {
+				messages = append(messages, fmt.Sprintf("%s.%s: 字段逻辑 %q calls unknown functions %s", t.Name, f.Name, f.Logic, strings.Join(unknown, ", ")))
+			}
+		}
+	}
+	return messages
+}
+
+// readUDFs reads a custom function registry file (see generator.ParseUDFRegistry); an
+// empty file name returns a nil registry, i.e. no functions to register.
+func readUDFs(file string) (generator.UDFRegistry, error) {
+	if file == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	udfs, err := generator.ParseUDFRegistry(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return udfs, nil
+}